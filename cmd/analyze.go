@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/felipekafuri/fk-converter/converter"
+	"github.com/spf13/cobra"
+)
+
+var analyzeSample time.Duration
+
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze <input-file>",
+	Short: "Estimate a conversion's time and size from a sample encode",
+	Long: `Encode a short sample from the middle of a video with the given conversion
+settings and project the full conversion's encode time and output size,
+without converting the whole file.
+
+Because it measures the real encoder on the real content, it is a good
+basis for planning the time and disk space of large batch jobs.
+
+Examples:
+  fk-converter analyze movie.mkv
+  fk-converter analyze movie.mkv --codec h265 -q high -r 1080p --duration 30s`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := converter.CheckFFmpeg(); err != nil {
+			return err
+		}
+
+		opts := newOptions(args[0])
+
+		fmt.Fprintf(stdout, "Analyzing: %s (%s sample)\n", opts.Input, analyzeSample)
+
+		bar := newProgressBar("Encoding sample")
+		e, err := converter.EstimateConversion(opts, analyzeSample, func(percent float64) {
+			bar.Set(int(percent))
+		})
+		if err != nil {
+			fmt.Fprintln(stdout)
+			return err
+		}
+		bar.Finish()
+
+		fmt.Printf("\nSample: %s encoded in %s (%.1fx realtime), %.2f MB\n",
+			e.SampleDuration, e.SampleTime.Round(time.Millisecond), e.Speed, float64(e.SampleSize)/1024/1024)
+		fmt.Printf("Projected for %s of output (%s, quality %s):\n", e.Duration.Round(time.Second), opts.Format, opts.Quality)
+		fmt.Printf("  Encode time: %s\n", e.EncodeTime.Round(time.Second))
+		fmt.Printf("  Output size: %.1f MB\n", float64(e.Size)/1024/1024)
+		fmt.Printf("  Bitrate:     %.2f Mb/s\n", float64(e.Bitrate)/1000/1000)
+		return nil
+	},
+}
+
+func init() {
+	addConversionFlags(analyzeCmd)
+	analyzeCmd.Flags().DurationVar(&analyzeSample, "duration", 10*time.Second, "Length of the sample to encode")
+
+	rootCmd.AddCommand(analyzeCmd)
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/felipekafuri/fk-converter/converter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	audiobookOutput   string
+	audiobookChapters string
+	audiobookCover    string
+	audiobookBitrate  string
+	audiobookTitle    string
+	audiobookAuthor   string
+)
+
+var audiobookCmd = &cobra.Command{
+	Use:   "audiobook <input-file>",
+	Short: "Make an m4b audiobook (or m4a) with chapters and cover art",
+	Long: `Convert long-form audio (or the soundtrack of a video) to an m4b
+audiobook or m4a file: AAC audio with chapter markers for navigation and
+cover art. An AAC input is copied as is unless --bitrate is given.
+
+--chapters takes the same file as convert: one "timestamp title" line
+per chapter, or ffmetadata.
+
+Examples:
+  fk-converter audiobook book.mp3 --chapters chapters.txt --cover cover.jpg
+  fk-converter audiobook lecture.mp4 -o lecture.m4a --bitrate 96k --title "Lecture 1" --author "J. Doe"`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := converter.CheckFFmpeg(); err != nil {
+			return err
+		}
+
+		opts := &converter.AudiobookOptions{
+			Input:    args[0],
+			Output:   audiobookOutput,
+			Chapters: audiobookChapters,
+			CoverArt: audiobookCover,
+			Bitrate:  audiobookBitrate,
+			Title:    audiobookTitle,
+			Author:   audiobookAuthor,
+		}
+
+		converter.ResolveAudiobookOptions(opts)
+		if err := converter.ValidateAudiobookOptions(opts); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(stdout, "Audiobook: %s → %s\n", opts.Input, opts.Output)
+
+		bar := newProgressBar("Encoding")
+		start := time.Now()
+
+		err := converter.Audiobook(opts, func(percent float64) {
+			bar.Set(int(percent))
+		})
+		if err != nil {
+			fmt.Fprintln(stdout)
+			return err
+		}
+
+		bar.Finish()
+		elapsed := time.Since(start).Round(time.Millisecond)
+
+		fmt.Fprintf(stdout, "\nDone in %s → %s\n", elapsed, opts.Output)
+		return nil
+	},
+}
+
+func init() {
+	audiobookCmd.Flags().StringVarP(&audiobookOutput, "output", "o", "", "Output file, .m4b or .m4a (default: name.m4b)")
+	audiobookCmd.Flags().StringVar(&audiobookChapters, "chapters", "", "Add chapters from a file of \"timestamp title\" lines or ffmetadata")
+	audiobookCmd.Flags().StringVar(&audiobookCover, "cover", "", "Cover art image (jpg or png)")
+	audiobookCmd.Flags().StringVar(&audiobookBitrate, "bitrate", "", "AAC bitrate, e.g. 96k (default: 64k, or copy an AAC input)")
+	audiobookCmd.Flags().StringVar(&audiobookTitle, "title", "", "Title tag")
+	audiobookCmd.Flags().StringVar(&audiobookAuthor, "author", "", "Author, written to the artist tag")
+
+	rootCmd.AddCommand(audiobookCmd)
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/felipekafuri/fk-converter/converter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	batchKeepGoing bool
+	batchFailFast  bool
+
+	normalizeRotation bool
+	skipExisting      bool
+)
+
+var batchCmd = &cobra.Command{
+	Use:   "batch [input-file]...",
+	Short: "Convert several video files with the same settings",
+	Long: `Convert several video files with the same settings.
+
+This is the same as passing several files to convert. Each output is
+named after its input (video_converted.mp4, ...), in --output-dir if
+given. By default a failed file is reported and the batch continues;
+--fail-fast (or --keep-going=false) stops at the first failure. The exit
+code is non-zero if any file failed.
+
+Examples:
+  fk-converter batch a.mov b.mov c.mov -f mp4 -q high
+  fk-converter batch *.avi -r 720p --fail-fast
+  fk-converter batch --from-file inputs.txt`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := converter.CheckFFmpeg(); err != nil {
+			return err
+		}
+
+		jobs, err := collectJobs(args)
+		if err != nil {
+			return err
+		}
+		return runBatch(jobs)
+	},
+}
+
+// runBatch runs every job, reporting each file and continuing past
+// failures unless --fail-fast is set or --keep-going is turned off.
+func runBatch(jobs []*converter.Options) error {
+	if err := createOutputDir(); err != nil {
+		return err
+	}
+
+	progress, err := openProgressFD()
+	if err != nil {
+		return err
+	}
+
+	if normalizeRotation {
+		for _, opts := range jobs {
+			opts.RotateAuto = true
+		}
+	}
+
+	var bar progressDisplay
+	var start time.Time
+	var rotation, rotated, skipped, finished int
+	batchStart := time.Now()
+
+	batch := &converter.Batch{
+		Jobs:         jobs,
+		FailFast:     batchFailFast || !batchKeepGoing,
+		SkipUpToDate: skipExisting,
+		OnSkip: func(i int, opts *converter.Options) {
+			fmt.Fprintf(stdout, "\n[%d/%d] Skipping %s: %s is up to date\n", i+1, len(jobs), opts.Input, opts.Output)
+			skipped++
+		},
+		OnStart: func(i int, opts *converter.Options) {
+			fmt.Fprintf(stdout, "\n[%d/%d] ", i+1, len(jobs))
+			printSummary(opts)
+			if normalizeRotation {
+				rotation = sourceRotation(opts.Input)
+			}
+			prefix := fmt.Sprintf("[%d/%d] %s", i+1, len(jobs), filepath.Base(opts.Input))
+			bar = newProgressBar(prefix)
+			opts.OnProgressDetail = func(p converter.Progress) {
+				describeETA(bar, prefix, p)
+				progress.report(opts.Input, p)
+			}
+			start = time.Now()
+		},
+		OnProgress: func(i int, percent float64) {
+			bar.Set(int(percent))
+		},
+		OnFinish: func(i int, opts *converter.Options, err error) {
+			progress.end(opts.Input, err)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "\nFailed: %s: %v\n", opts.Input, err)
+			} else {
+				bar.Finish()
+				printDone(opts, time.Since(start).Round(time.Millisecond))
+				if rotation != 0 {
+					fmt.Fprintf(stdout, "Rotated %d° upright\n", rotation)
+					rotated++
+				}
+			}
+			if hookErr := runHook(opts, err); hookErr != nil {
+				fmt.Fprintln(os.Stderr, hookErr)
+			}
+			finished++
+			if remaining := len(jobs) - i - 1; remaining > 0 {
+				printBatchStatus(i+1, len(jobs), finished, remaining, time.Since(batchStart))
+			}
+		},
+	}
+
+	batchErr := converter.ConvertBatch(batch)
+	if normalizeRotation {
+		fmt.Fprintf(stdout, "\nRotated %d of %d files upright\n", rotated, len(jobs))
+	}
+	if batchErr != nil {
+		fmt.Fprintln(stdout)
+		return batchErr
+	}
+
+	if skipped > 0 {
+		fmt.Fprintf(stdout, "\n%d files converted, %d skipped (up to date) in %s\n", len(jobs)-skipped, skipped, time.Since(batchStart).Round(time.Second))
+		return nil
+	}
+	fmt.Fprintf(stdout, "\nAll %d files converted in %s\n", len(jobs), time.Since(batchStart).Round(time.Second))
+	return nil
+}
+
+// printBatchStatus shows how far the batch is, with a time left estimated
+// from the average time of the files converted so far. Files that turn out
+// to be skipped make it an overestimate.
+func printBatchStatus(done, total, finished, remaining int, elapsed time.Duration) {
+	left := elapsed / time.Duration(finished) * time.Duration(remaining)
+	fmt.Fprintf(stdout, "Batch: %d of %d files done in %s, about %s left\n",
+		done, total, elapsed.Round(time.Second), left.Round(time.Second))
+}
+
+// sourceRotation returns the rotation metadata of the input's video, or 0
+// if it can't be read.
+func sourceRotation(input string) int {
+	info, err := converter.Probe(input)
+	if err != nil {
+		return 0
+	}
+	if v := info.VideoStream(); v != nil {
+		return v.Rotation
+	}
+	return 0
+}
+
+// addBatchFlags registers the flags of commands converting several files.
+func addBatchFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&outputDir, "output-dir", "", "Write outputs to this directory (created if missing)")
+	cmd.Flags().BoolVar(&normalizeRotation, "normalize-rotation", false, "Turn every file upright by its rotation metadata (like --rotate-auto) and report how many were rotated")
+	cmd.Flags().StringVar(&fromFile, "from-file", "", "Also convert the files listed in this file, one per line (or input,output rows in a .csv)")
+	cmd.Flags().BoolVar(&skipExisting, "skip-existing", false, "Skip files whose output already exists and is not older than the input (like make)")
+	cmd.Flags().BoolVar(&batchKeepGoing, "keep-going", true, "Continue with the remaining files after a failure")
+	cmd.Flags().BoolVar(&batchFailFast, "fail-fast", false, "Stop at the first failed file")
+	cmd.MarkFlagsMutuallyExclusive("keep-going", "fail-fast")
+}
+
+func init() {
+	addConversionFlags(batchCmd)
+	addBatchFlags(batchCmd)
+	addHookFlags(batchCmd)
+	batchCmd.Flags().IntVar(&progressFD, "progress-fd", 0, "Also write JSON progress lines to this inherited file descriptor (for GUIs)")
+
+	rootCmd.AddCommand(batchCmd)
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/felipekafuri/fk-converter/converter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	benchCodecs    string
+	benchQualities string
+	benchDuration  time.Duration
+	benchMetrics   bool
+)
+
+var benchmarkCmd = &cobra.Command{
+	Use:   "benchmark <input-file>",
+	Short: "Compare codecs and quality presets on a sample",
+	Long: `Encode a short sample of a video with several codec/quality
+combinations and compare encode time, output size and, with --metrics,
+visual quality (PSNR, plus VMAF if ffmpeg was built with libvmaf).
+
+Examples:
+  fk-converter benchmark video.mov
+  fk-converter benchmark video.mov --codecs h264,h265 --qualities medium,high --metrics`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := converter.CheckFFmpeg(); err != nil {
+			return err
+		}
+
+		opts := &converter.BenchmarkOptions{
+			Input:          args[0],
+			SampleDuration: benchDuration,
+			Metrics:        benchMetrics,
+		}
+		for _, c := range strings.Split(benchCodecs, ",") {
+			for _, q := range strings.Split(benchQualities, ",") {
+				opts.Cases = append(opts.Cases, converter.BenchmarkCase{
+					Codec:   strings.TrimSpace(c),
+					Quality: converter.Quality(strings.TrimSpace(q)),
+				})
+			}
+		}
+
+		fmt.Printf("Benchmarking %d combinations on the first %s of %s\n\n", len(opts.Cases), opts.SampleDuration, opts.Input)
+
+		row := "%-6s %-9s %-10s %-10s %-10s %s\n"
+		fmt.Printf(row, "CODEC", "QUALITY", "TIME", "SIZE", "PSNR", "VMAF")
+
+		_, err := converter.Benchmark(opts, func(r converter.BenchmarkResult) {
+			if r.Err != nil {
+				fmt.Printf("%-6s %-9s failed: %v\n", r.Codec, r.Quality, r.Err)
+				return
+			}
+			psnr, vmaf := "-", "-"
+			if r.Scores != nil {
+				psnr = fmt.Sprintf("%.2f dB", r.Scores.PSNR)
+				if r.Scores.HasVMAF {
+					vmaf = fmt.Sprintf("%.2f", r.Scores.VMAF)
+				}
+			}
+			fmt.Printf(row, r.Codec, r.Quality, r.EncodeTime.Round(time.Millisecond),
+				fmt.Sprintf("%.2f MB", float64(r.Size)/1024/1024), psnr, vmaf)
+		})
+		return err
+	},
+}
+
+func init() {
+	benchmarkCmd.Flags().StringVar(&benchCodecs, "codecs", "h264,h265,vp9", "Comma-separated codecs to try")
+	benchmarkCmd.Flags().StringVar(&benchQualities, "qualities", "medium,high", "Comma-separated quality presets to try")
+	benchmarkCmd.Flags().DurationVar(&benchDuration, "duration", 10*time.Second, "Length of the sample to encode")
+	benchmarkCmd.Flags().BoolVar(&benchMetrics, "metrics", false, "Also measure PSNR/VMAF against the source (slow)")
+
+	rootCmd.AddCommand(benchmarkCmd)
+}
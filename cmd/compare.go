@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/felipekafuri/fk-converter/converter"
+	"github.com/spf13/cobra"
+)
+
+var compareCmd = &cobra.Command{
+	Use:   "compare <original> <converted>",
+	Short: "Measure the quality of a conversion against its original",
+	Long: `Measure how close a converted video is to its original with PSNR,
+SSIM and, if ffmpeg was built with libvmaf, VMAF.
+
+The converted video is scaled and retimed to the original's resolution and
+frame rate first, so resized outputs can be compared too.
+
+Examples:
+  fk-converter compare video.mov video_converted.mp4`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := converter.CheckFFmpeg(); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(stdout, "Comparing %s against %s\n\n", args[1], args[0])
+
+		scores, err := converter.Compare(args[0], args[1])
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("PSNR: %.2f dB\n", scores.PSNR)
+		fmt.Printf("SSIM: %.4f\n", scores.SSIM)
+		if scores.HasVMAF {
+			fmt.Printf("VMAF: %.2f\n", scores.VMAF)
+		} else {
+			fmt.Println("VMAF: unavailable (ffmpeg was built without libvmaf)")
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(compareCmd)
+}
@@ -1,105 +1,621 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/felipekafuri/fk-converter/converter"
-	"github.com/schollz/progressbar/v3"
 	"github.com/spf13/cobra"
 )
 
 var (
 	output     string
+	outputDir  string
 	format     string
 	quality    string
 	resolution string
 	codec      string
+	loop       int
+
+	maxResolution string
+
+	extractAudio string
+	audioTrack   int
+	reportFormat string
+
+	profile string
+	level   string
+
+	deinterlace     bool
+	deinterlaceMode string
+	detectInterlace bool
+	interlace       string
+
+	hwDecode string
+
+	customVideoFilter string
+	customAudioFilter string
+
+	sampleDuration time.Duration
+
+	rateControl string
+
+	nameTemplate string
+
+	keyframeInterval int
+	keyframeEvery    time.Duration
+	sceneDetect      float64
+
+	noFastStart bool
+	fragmented  bool
+
+	inPlace bool
+
+	sampleRate int
+
+	coverArt          string
+	defaultAudioTrack int
+
+	replaceAudio string
+
+	constantFrameRate bool
+
+	presetBundle string
+
+	threads int
+
+	skipProbe bool
+
+	verify     bool
+	verifyFull bool
+
+	audioDelay time.Duration
+
+	x264Params string
+	x265Params string
+
+	tune string
+
+	mapAll bool
+
+	aspectRatio string
+
+	readRate float64
+
+	colorSpace string
+
+	backgroundAudio  string
+	backgroundVolume float64
+
+	scaleAlgorithm string
+
+	dropDuplicates bool
+
+	deterministic bool
+
+	stillAudio string
+
+	audioLanguages    []string
+	subtitleLanguages []string
+	subtitleFiles     []string
+
+	chapters string
+
+	start        time.Duration
+	accurateSeek bool
+
+	noAudioCopy bool
+
+	rotateAuto bool
+
+	maxDuration time.Duration
+
+	forceReencode bool
+
+	allowFallback bool
+
+	fileMode fileModeFlag
+
+	preserveTimestamps string
+
+	nice int
+
+	maxSize       sizeFlag
+	minQualityCRF int
+
+	audioBitrate string
+
+	downmixStereo bool
+
+	rawArgs rawArgsFlag
+
+	autoQuality bool
+
+	smoothFPS float64
+
+	muteRanges []string
+	channelMap string
+
+	targetLUFS      float64
+	targetTruePeak  float64
+	targetLRA       float64
+	loudnessTwoPass bool
 )
 
 var convertCmd = &cobra.Command{
-	Use:   "convert <input-file>",
-	Short: "Convert a video file",
-	Long: `Convert a video file to a different format and/or quality.
+	Use:   "convert [input-file]...",
+	Short: "Convert video files",
+	Long: `Convert video files to a different format and/or quality.
+
+With several inputs (or a glob pattern, or a --from-file list), each file
+is converted with the same settings and named after its input, in
+--output-dir if given.
 
 Examples:
   fk-converter convert video.mov -o output.mp4
   fk-converter convert video.avi -f mkv -q high
   fk-converter convert video.mp4 -r 720p -q low
-  fk-converter convert video.mov --codec h265 -q high -o compressed.mp4`,
-	Args: cobra.ExactArgs(1),
+  fk-converter convert video.mov --codec h265 -q high -o compressed.mp4
+  fk-converter convert clip.mp4 --loop 4 -o background.mp4
+  fk-converter convert movie.mkv -o movie.mp4 --extract-audio dub.flac --audio-track 1
+  fk-converter convert video.mkv --profile high --level 4.1 -o tv.mp4
+  fk-converter convert capture.avi --deinterlace --deinterlace-mode bwdif
+  fk-converter convert video.mov --codec h265 -q low --sample 10s
+  fk-converter convert gameplay.mkv --preset-bundle discord
+  fk-converter convert capture.mp4 --audio-delay -0.5s
+  fk-converter convert movie.mp4 -o movie.mkv --map-all
+  fk-converter convert screencast.mov --background-audio music.mp3 --background-volume 0.2
+  fk-converter convert cover.jpg --audio song.flac -o song.mp4
+  fk-converter convert talk.mov --on-complete 'rclone copy "$FK_OUTPUT" remote:videos'
+  fk-converter convert *.mov -f mp4 --output-dir converted
+  fk-converter convert clip.mov --tune animation -q high --sample 10s --preview`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if err := converter.CheckFFmpeg(); err != nil {
 			return err
 		}
 
-		opts := &converter.Options{
-			Input:      args[0],
-			Output:     output,
-			Format:     format,
-			Quality:    converter.Quality(quality),
-			Resolution: resolution,
-			Codec:      codec,
+		jobs, err := collectJobs(args)
+		if err != nil {
+			return err
+		}
+		if len(jobs) > 1 || fromFile != "" {
+			if output != "" {
+				return fmt.Errorf("--output needs a single input (got %d); use --output-dir or --name-template", len(jobs))
+			}
+			if reportFormat != "" {
+				return fmt.Errorf("--report needs a single input (got %d)", len(jobs))
+			}
+			if preview {
+				return fmt.Errorf("--preview needs a single input (got %d)", len(jobs))
+			}
+			return runBatch(jobs)
 		}
 
-		converter.ResolveOutput(opts)
-
-		if err := converter.ValidateOptions(opts); err != nil {
+		if err := createOutputDir(); err != nil {
 			return err
 		}
 
-		fmt.Printf("Converting: %s → %s\n", opts.Input, opts.Output)
-		fmt.Printf("Format: %s | Quality: %s", opts.Format, opts.Quality)
-		if opts.Resolution != "" {
-			fmt.Printf(" | Resolution: %s", opts.Resolution)
+		opts := jobs[0]
+		opts.Output = output
+		return runSingle(opts)
+	},
+}
+
+// runSingle converts one file with a progress bar, or prints a report with
+// --report.
+func runSingle(opts *converter.Options) error {
+	converter.ResolveOutput(opts)
+
+	if err := converter.ValidateOptions(opts); err != nil {
+		return err
+	}
+
+	if skipExisting && converter.UpToDate(opts) {
+		fmt.Fprintf(stdout, "Skipping %s: %s is up to date\n", opts.Input, opts.Output)
+		return nil
+	}
+
+	if reportFormat != "" {
+		return runWithReport(opts)
+	}
+
+	progress, err := openProgressFD()
+	if err != nil {
+		return err
+	}
+
+	printSummary(opts)
+
+	bar := newProgressBar("Converting")
+	opts.OnProgressDetail = func(p converter.Progress) {
+		describeETA(bar, "Converting", p)
+		progress.report(opts.Input, p)
+	}
+
+	start := time.Now()
+
+	err = converter.Convert(opts, func(percent float64) {
+		bar.Set(int(percent))
+	})
+	progress.end(opts.Input, err)
+	if err != nil {
+		fmt.Fprintln(stdout)
+		if hookErr := runHook(opts, err); hookErr != nil {
+			fmt.Fprintln(os.Stderr, hookErr)
+		}
+		return err
+	}
+
+	bar.Finish()
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	printDone(opts, elapsed)
+	if preview {
+		previewFile(opts.Output)
+	}
+	return runHook(opts, nil)
+}
+
+func newOptions(input string) *converter.Options {
+	opts := &converter.Options{
+		Input:      input,
+		Format:     format,
+		Quality:    converter.Quality(quality),
+		Resolution: resolution,
+		Codec:      codec,
+		Loop:       loop,
+
+		MaxResolution: maxResolution,
+
+		ExtractAudio: extractAudio,
+		AudioTrack:   audioTrack,
+
+		Profile: profile,
+		Level:   level,
+
+		Deinterlace:     deinterlace,
+		DeinterlaceMode: deinterlaceMode,
+		DetectInterlace: detectInterlace,
+		Interlace:       interlace,
+
+		HWDecode: hwDecode,
+
+		CustomVideoFilter: customVideoFilter,
+		CustomAudioFilter: customAudioFilter,
+
+		SampleDuration: sampleDuration,
+
+		RateControl: converter.RateControl(rateControl),
+
+		NameTemplate: nameTemplate,
+
+		KeyframeInterval: keyframeInterval,
+		KeyframeEvery:    keyframeEvery,
+		SceneDetect:      sceneDetect,
+
+		NoFastStart: noFastStart,
+		Fragmented:  fragmented,
+
+		InPlace: inPlace,
+
+		OutputDir: outputDir,
+
+		SampleRate: sampleRate,
+
+		CoverArt: coverArt,
+
+		ReplaceAudio: replaceAudio,
+
+		ConstantFrameRate: constantFrameRate,
+
+		PresetBundle: presetBundle,
+
+		Threads: threads,
+
+		SkipProbe: skipProbe,
+
+		Verify:     verify,
+		VerifyFull: verifyFull,
+
+		AudioDelay: audioDelay,
+
+		X264Params: x264Params,
+		X265Params: x265Params,
+
+		Tune: tune,
+
+		MapAll: mapAll,
+
+		AspectRatio: aspectRatio,
+
+		ReadRate: readRate,
+
+		ColorSpace: colorSpace,
+
+		BackgroundAudio:  backgroundAudio,
+		BackgroundVolume: backgroundVolume,
+
+		ScaleAlgorithm: scaleAlgorithm,
+
+		DropDuplicates: dropDuplicates,
+
+		Deterministic: deterministic,
+
+		Audio: stillAudio,
+
+		AudioLanguages:    audioLanguages,
+		SubtitleLanguages: subtitleLanguages,
+		Subtitles:         parseSubtitleFiles(subtitleFiles),
+
+		Chapters: chapters,
+
+		Start:        start,
+		AccurateSeek: accurateSeek,
+
+		NoAudioCopy: noAudioCopy,
+
+		RotateAuto: rotateAuto,
+
+		MaxDuration: maxDuration,
+
+		ForceReencode: forceReencode,
+
+		Fallback: allowFallback,
+
+		FileMode: os.FileMode(fileMode),
+
+		PreserveTimestamps: converter.TimestampSource(preserveTimestamps),
+
+		Nice: nice,
+
+		MaxSize:       int64(maxSize),
+		MinQualityCRF: minQualityCRF,
+		OnFitAttempt:  printFitAttempt,
+
+		AudioBitrate: audioBitrate,
+
+		DownmixStereo: downmixStereo,
+
+		ExtraArgs: rawArgs,
+
+		AutoQuality: autoQuality,
+
+		SmoothFPS: smoothFPS,
+
+		Mute:       muteRanges,
+		ChannelMap: channelMap,
+
+		TargetLUFS:      targetLUFS,
+		TargetTruePeak:  targetTruePeak,
+		TargetLRA:       targetLRA,
+		LoudnessTwoPass: loudnessTwoPass,
+	}
+	if defaultAudioTrack >= 0 {
+		opts.DefaultAudioTrack = &defaultAudioTrack
+	}
+	return opts
+}
+
+func printSummary(opts *converter.Options) {
+	if len(opts.Renditions) > 0 {
+		fmt.Fprintf(stdout, "Converting: %s → %d renditions\n", opts.Input, len(opts.Renditions))
+	} else {
+		fmt.Fprintf(stdout, "Converting: %s → %s\n", opts.Input, opts.Output)
+	}
+	fmt.Fprintf(stdout, "Format: %s | Quality: %s", opts.Format, opts.Quality)
+	if opts.RateControl == converter.RateControlBitrate {
+		fmt.Fprintf(stdout, " (bitrate)")
+	} else if opts.AutoQuality {
+		fmt.Fprintf(stdout, " (auto)")
+	}
+	if opts.Resolution != "" {
+		fmt.Fprintf(stdout, " | Resolution: %s", opts.Resolution)
+	}
+	if opts.Codec != "" {
+		fmt.Fprintf(stdout, " | Codec: %s", opts.Codec)
+	}
+	if opts.Loop > 0 {
+		fmt.Fprintf(stdout, " | Loop: %dx", opts.Loop)
+	}
+	if opts.Profile != "" {
+		fmt.Fprintf(stdout, " | Profile: %s", opts.Profile)
+	}
+	if opts.Level != "" {
+		fmt.Fprintf(stdout, " | Level: %s", opts.Level)
+	}
+	if opts.Tune != "" {
+		fmt.Fprintf(stdout, " | Tune: %s", opts.Tune)
+	}
+	if opts.Deinterlace {
+		fmt.Fprintf(stdout, " | Deinterlace")
+	}
+	if opts.HWDecode != "" {
+		fmt.Fprintf(stdout, " | HW decode: %s", opts.HWDecode)
+	}
+	if opts.Start > 0 {
+		fmt.Fprintf(stdout, " | Start: %s", opts.Start)
+	}
+	if opts.SampleDuration > 0 {
+		fmt.Fprintf(stdout, " | Sample: %s", opts.SampleDuration)
+	}
+	fmt.Fprintln(stdout)
+	if converter.WillRemux(opts) {
+		fmt.Fprintln(stdout, "No re-encode needed, remuxing")
+	}
+}
+
+// printFitAttempt reports each encode made to fit --max-size.
+func printFitAttempt(attempt, crf int, size int64) {
+	verdict := "fits"
+	if size > int64(maxSize) {
+		verdict = "over"
+	}
+	fmt.Fprintf(stdout, "\nAttempt %d at CRF %d: %.1f MB, %s the %.1f MB budget\n",
+		attempt, crf, float64(size)/1024/1024, verdict, float64(maxSize)/1024/1024)
+}
+
+func printDone(opts *converter.Options, elapsed time.Duration) {
+	if len(opts.Renditions) > 0 {
+		fmt.Fprintf(stdout, "\nDone in %s\n", elapsed)
+		printRenditions(opts.Renditions)
+		if opts.ExtractAudio != "" {
+			fmt.Fprintf(stdout, "Audio track %d → %s\n", opts.AudioTrack, opts.ExtractAudio)
 		}
-		if opts.Codec != "" {
-			fmt.Printf(" | Codec: %s", opts.Codec)
+		return
+	}
+
+	info, _ := os.Stat(opts.Output)
+	size := ""
+	if info != nil {
+		mb := float64(info.Size()) / 1024 / 1024
+		size = fmt.Sprintf(" (%.1f MB)", mb)
+	}
+
+	fmt.Fprintf(stdout, "\nDone in %s → %s%s\n", elapsed, opts.Output, size)
+	if stats, err := converter.Stats(opts); err == nil {
+		fmt.Fprintf(stdout, "Bitrate: %.2f Mb/s", float64(stats.BitRate)/1e6)
+		if stats.Width > 0 {
+			fmt.Fprintf(stdout, " | Resolution: %dx%d", stats.Width, stats.Height)
 		}
-		fmt.Println()
-
-		bar := progressbar.NewOptions(100,
-			progressbar.OptionSetDescription("Converting"),
-			progressbar.OptionSetWidth(40),
-			progressbar.OptionShowBytes(false),
-			progressbar.OptionSetPredictTime(true),
-			progressbar.OptionThrottle(100*time.Millisecond),
-			progressbar.OptionShowCount(),
-			progressbar.OptionClearOnFinish(),
-		)
-
-		start := time.Now()
-
-		err := converter.Convert(opts, func(percent float64) {
-			bar.Set(int(percent))
-		})
-		if err != nil {
-			fmt.Fprintln(os.Stderr)
-			return err
+		if stats.CompressionRatio >= 1 {
+			fmt.Fprintf(stdout, " | %.1fx smaller than source", stats.CompressionRatio)
+		} else if stats.CompressionRatio > 0 {
+			fmt.Fprintf(stdout, " | %.1fx larger than source", 1/stats.CompressionRatio)
 		}
+		fmt.Fprintln(stdout)
+	}
+	if opts.ExtractAudio != "" {
+		fmt.Fprintf(stdout, "Audio track %d → %s\n", opts.AudioTrack, opts.ExtractAudio)
+	}
+}
+
+// describeETA shows the converter's smoothed speed and ETA next to the bar.
+// It is steadier than a prediction from the bar's own update rate.
+func describeETA(bar progressDisplay, description string, p converter.Progress) {
+	if p.Speed <= 0 {
+		return
+	}
+	bar.Describe(fmt.Sprintf("%s %.1fx, ETA %s", description, p.Speed, p.ETA))
+}
 
-		bar.Finish()
-		elapsed := time.Since(start).Round(time.Millisecond)
+func runWithReport(opts *converter.Options) error {
+	if reportFormat != "json" {
+		return fmt.Errorf("unsupported report format: %s (supported: json)", reportFormat)
+	}
 
-		info, _ := os.Stat(opts.Output)
-		size := ""
-		if info != nil {
-			mb := float64(info.Size()) / 1024 / 1024
-			size = fmt.Sprintf(" (%.1f MB)", mb)
+	start := time.Now()
+	convErr := converter.Convert(opts, nil)
+	report := converter.NewReport(opts, time.Since(start), convErr)
+
+	if err := json.NewEncoder(os.Stdout).Encode(report); err != nil {
+		return err
+	}
+	if hookErr := runHook(opts, convErr); hookErr != nil {
+		if convErr != nil {
+			fmt.Fprintln(os.Stderr, hookErr)
+			return convErr
 		}
+		return hookErr
+	}
+	return convErr
+}
 
-		fmt.Printf("\nDone in %s → %s%s\n", elapsed, opts.Output, size)
-		return nil
-	},
+// addConversionFlags registers the flags shared by every command that
+// converts files. Commands handling several inputs don't get --output.
+func addConversionFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&format, "format", "f", "", "Output format (mp4, mkv, webm, avi, mov)")
+	cmd.Flags().StringVarP(&quality, "quality", "q", "", "Quality preset: low, medium, high, lossless (default: medium)")
+	cmd.Flags().BoolVar(&autoQuality, "auto-quality", false, "Adjust the quality preset's CRF to the output resolution (higher for 4K, lower for SD)")
+	cmd.Flags().StringVarP(&resolution, "resolution", "r", "", "Target resolution (e.g. 1080p, 720p, 480p)")
+	cmd.Flags().StringVar(&maxResolution, "max-resolution", "", "Scale down inputs larger than this (e.g. 1080p or 1920x1080), never up")
+	cmd.Flags().StringVar(&scaleAlgorithm, "scale-algorithm", "", "Scaler used with --resolution: bilinear, bicubic, lanczos, spline, neighbor, area (default: bicubic)")
+	cmd.Flags().StringVar(&codec, "codec", "", "Video codec (h264, h265, vp9)")
+	cmd.Flags().BoolVar(&allowFallback, "allow-fallback", false, "Encode with h264 when ffmpeg lacks the h265/vp9 encoder, instead of failing")
+	cmd.Flags().IntVar(&loop, "loop", 0, "Repeat the input N extra times in the output")
+	cmd.Flags().StringVar(&extractAudio, "extract-audio", "", "Also save an audio track to this file (mp3, m4a, aac, opus, ogg, flac, wav)")
+	cmd.Flags().IntVar(&audioTrack, "audio-track", 0, "Audio track index used by --extract-audio")
+	cmd.Flags().StringVar(&profile, "profile", "", "Encoder profile (h264: baseline, main, high; h265: main, main10)")
+	cmd.Flags().StringVar(&level, "level", "", "Encoder level (e.g. 3.1, 4.1)")
+	cmd.Flags().StringVar(&tune, "tune", "", "Encoder tuning for the content (h264/h265): film, animation, grain, stillimage, zerolatency, ...")
+	cmd.Flags().BoolVar(&rotateAuto, "rotate-auto", false, "Apply phone rotation metadata to the pixels so the output plays upright everywhere")
+	cmd.Flags().StringVar(&colorSpace, "color-space", "", "Convert to this color space and tag the output (bt601, bt709, bt2020)")
+	cmd.Flags().StringVar(&aspectRatio, "aspect", "", "Override the display aspect ratio without rescaling (e.g. 16:9, 4:3)")
+	cmd.Flags().BoolVar(&deinterlace, "deinterlace", false, "Deinterlace the video")
+	cmd.Flags().StringVar(&deinterlaceMode, "deinterlace-mode", "", "Deinterlace filter: yadif, bwdif (default: yadif)")
+	cmd.Flags().StringVar(&interlace, "interlace", "", "Produce interlaced h264 output with this field order: tff, bff (for broadcast delivery)")
+	cmd.Flags().Lookup("interlace").NoOptDefVal = "tff"
+	cmd.Flags().BoolVar(&detectInterlace, "detect-interlace", false, "Deinterlace only if the input is detected as interlaced")
+	cmd.Flags().StringVar(&hwDecode, "hw-decode", "", "Decode on the GPU (cuda, videotoolbox, qsv, vaapi)")
+	cmd.Flags().StringVar(&customVideoFilter, "vf", "", "Extra ffmpeg video filters, appended to the generated chain")
+	cmd.Flags().StringVar(&customAudioFilter, "af", "", "Extra ffmpeg audio filters, appended to the generated chain")
+	cmd.Flags().Var(&rawArgs, "raw-args", "Extra ffmpeg output arguments, added before the output path ({input} and {output} are replaced)")
+	cmd.Flags().DurationVar(&start, "start", 0, "Skip the beginning of the input, e.g. 1m30s")
+	cmd.Flags().BoolVar(&accurateSeek, "accurate-seek", false, "Reach --start by decoding instead of seeking (slower, always frame-exact)")
+	cmd.Flags().DurationVar(&maxDuration, "max-duration", 0, "Cut outputs longer than this (e.g. 10m), for platform length limits")
+	cmd.Flags().DurationVar(&sampleDuration, "sample", 0, "Only convert the first N seconds (e.g. 10s) to a _sample file")
+	cmd.Flags().StringVar(&x264Params, "x264-params", "", "Raw libx264 options, e.g. aq-mode=3:psy-rd=1.0 (h264 only)")
+	cmd.Flags().StringVar(&x265Params, "x265-params", "", "Raw libx265 options, e.g. aq-mode=3:pools=4 (h265 only)")
+	cmd.Flags().IntVar(&keyframeInterval, "gop", 0, "Maximum keyframe interval (GOP size) in frames")
+	cmd.Flags().DurationVar(&keyframeEvery, "keyframe-every", 0, "Force a keyframe at this interval (e.g. 2s), for HLS/DASH segments")
+	cmd.Flags().Float64Var(&sceneDetect, "scene-detect", 0, "Place a keyframe at every scene change, found in a first pass (optionally =threshold, 0-1)")
+	cmd.Flags().Lookup("scene-detect").NoOptDefVal = fmt.Sprint(converter.DefaultSceneThreshold)
+	cmd.Flags().BoolVar(&fragmented, "fragmented", false, "Write fragmented MP4/MOV for DASH and streaming servers")
+	cmd.Flags().BoolVar(&noFastStart, "no-faststart", false, "Don't move MP4/MOV metadata to the front of the file")
+	cmd.Flags().BoolVar(&forceReencode, "force-reencode", false, "Re-encode even when only the container changes and the streams could be copied")
+	cmd.Flags().BoolVar(&noAudioCopy, "no-audio-copy", false, "Always re-encode audio, even when the source codec fits the output container")
+	cmd.Flags().StringVar(&audioBitrate, "audio-bitrate", "", "Audio bitrate, e.g. 160k (default: 96k/128k/192k for low/medium/high quality)")
+	cmd.Flags().BoolVar(&downmixStereo, "downmix-stereo", false, "Mix 5.1/7.1 surround down to stereo, keeping dialog and surrounds audible")
+	cmd.Flags().IntVar(&sampleRate, "sample-rate", 0, "Audio sample rate in Hz (e.g. 16000 for speech, 48000)")
+	cmd.Flags().BoolVar(&mapAll, "map-all", false, "Keep every stream (all audio, subtitles, attachments), copied unless encoding options are set")
+	cmd.Flags().StringSliceVar(&audioLanguages, "audio-lang", nil, "Language of each output audio track, in order (ISO 639-2, e.g. eng,jpn)")
+	cmd.Flags().Float64Var(&targetLUFS, "target-lufs", 0, "Normalize audio to this integrated loudness, e.g. -16 (podcasts) or -23 (EBU R128 broadcast)")
+	cmd.Flags().Float64Var(&targetTruePeak, "target-tp", 0, "Maximum true peak for --target-lufs in dBTP (default: -1.5)")
+	cmd.Flags().Float64Var(&targetLRA, "target-lra", 0, "Loudness range for --target-lufs in LU (default: 11)")
+	cmd.Flags().BoolVar(&loudnessTwoPass, "two-pass-loudness", false, "Measure the audio before normalizing with --target-lufs, for exact results (decodes the input twice)")
+	cmd.Flags().StringVar(&channelMap, "channel-map", "", "Relabel or remix audio channels: from->to layouts (e.g. 5.1->7.1), swap-lr, or a pan=... expression")
+	cmd.Flags().StringSliceVar(&muteRanges, "mute", nil, "Silence the audio from start to end, e.g. 1:05-1:12 (repeatable)")
+	cmd.Flags().StringArrayVar(&subtitleFiles, "subtitles", nil, "Add a subtitle file as a track, optionally with its language: subs.srt or subs.srt:spa (repeatable; mkv, mp4)")
+	cmd.Flags().StringSliceVar(&subtitleLanguages, "subtitle-lang", nil, "Language of each output subtitle track, in order (ISO 639-2, e.g. eng,spa)")
+	cmd.Flags().StringVar(&chapters, "chapters", "", "Add chapters from a file of \"timestamp title\" lines or ffmetadata")
+	cmd.Flags().StringVar(&coverArt, "cover-art", "", "Attach a jpg/png image as cover art (mkv only)")
+	cmd.Flags().IntVar(&defaultAudioTrack, "default-audio-track", -1, "Keep all audio tracks and mark this one as default (mkv only)")
+	cmd.Flags().StringVar(&stillAudio, "audio", "", "Turn an image input into a video with this audio file as soundtrack")
+	cmd.Flags().StringVar(&replaceAudio, "replace-audio", "", "Replace the soundtrack with this audio file")
+	cmd.Flags().DurationVar(&audioDelay, "audio-delay", 0, "Shift audio relative to video, e.g. 300ms (later) or -0.5s (earlier)")
+	cmd.Flags().StringVar(&backgroundAudio, "background-audio", "", "Mix this audio file (looped) under the soundtrack, e.g. background music")
+	cmd.Flags().Float64Var(&backgroundVolume, "background-volume", 0.25, "Volume of --background-audio (1 is unchanged)")
+	cmd.Flags().Float64Var(&smoothFPS, "smooth-fps", 0, "Convert to this frame rate with motion interpolation, e.g. 60 (smooth but very slow)")
+	cmd.Flags().BoolVar(&dropDuplicates, "dedup", false, "Drop duplicate frames to shrink mostly static recordings (output is VFR)")
+	cmd.Flags().BoolVar(&constantFrameRate, "cfr", false, "Force constant frame rate output (fixes A/V drift in screen recordings)")
+	cmd.Flags().IntVar(&threads, "threads", 0, "Limit ffmpeg to N encoder threads (default: 0, auto)")
+	cmd.Flags().Var(&maxSize, "max-size", "Size budget for the output, e.g. 50M; re-encodes at lower quality until it fits (needs --min-quality-fallback)")
+	cmd.Flags().IntVar(&minQualityCRF, "min-quality-fallback", 0, "Highest CRF (lowest quality) --max-size may fall back to, e.g. 30")
+	cmd.Flags().IntVar(&nice, "nice", 0, "Run ffmpeg at this lower CPU priority, 1-19 like nice(1), so the machine stays responsive (Unix only)")
+	cmd.Flags().Float64Var(&readRate, "readrate", 0, "Cap input read speed to N times realtime, e.g. 2 (spares shared/NAS storage)")
+	cmd.Flags().BoolVar(&deterministic, "deterministic", false, "Produce bit-identical output across runs (strips metadata, may run single-threaded)")
+	cmd.Flags().BoolVar(&skipProbe, "skip-probe", false, "Don't check that the input is a readable media file before converting")
+	cmd.Flags().BoolVar(&verify, "verify", false, "Check the output is readable and not truncated after converting")
+	cmd.Flags().BoolVar(&verifyFull, "verify-full", false, "Like --verify, and also decode the whole output to catch corruption")
+	cmd.Flags().Var(&fileMode, "chmod", "Set the output's permissions, in octal (e.g. 0644); default follows the umask")
+	cmd.Flags().StringVar(&preserveTimestamps, "preserve-timestamps", "", "Give the output the input's modification time (file), or the recording time from its metadata (media)")
+	cmd.Flags().Lookup("preserve-timestamps").NoOptDefVal = string(converter.TimestampFile)
+	cmd.Flags().BoolVar(&inPlace, "in-place", false, "Replace the input file with the converted one")
+	cmd.Flags().StringVar(&nameTemplate, "name-template", "", "Output name template, e.g. {name}_{quality}.{ext} (placeholders: name, ext, quality, codec, width, height)")
+	cmd.Flags().StringVar(&presetBundle, "preset-bundle", "", "Named option set: "+strings.Join(converter.PresetBundles(), ", ")+" (explicit flags override it)")
+	cmd.Flags().StringVar(&rateControl, "rate-control", "", "How quality presets are applied: crf, bitrate (default: crf)")
 }
 
 func init() {
 	convertCmd.Flags().StringVarP(&output, "output", "o", "", "Output file path")
-	convertCmd.Flags().StringVarP(&format, "format", "f", "", "Output format (mp4, mkv, webm, avi, mov)")
-	convertCmd.Flags().StringVarP(&quality, "quality", "q", "", "Quality preset: low, medium, high, lossless (default: medium)")
-	convertCmd.Flags().StringVarP(&resolution, "resolution", "r", "", "Target resolution (e.g. 1080p, 720p, 480p)")
-	convertCmd.Flags().StringVar(&codec, "codec", "", "Video codec (h264, h265, vp9)")
+	addConversionFlags(convertCmd)
+	addBatchFlags(convertCmd)
+	convertCmd.Flags().BoolVar(&preview, "preview", false, "Open the result in the default video player (or ffplay) when done")
+	addHookFlags(convertCmd)
+	convertCmd.Flags().IntVar(&progressFD, "progress-fd", 0, "Also write JSON progress lines to this inherited file descriptor (for GUIs)")
+	convertCmd.Flags().StringVar(&reportFormat, "report", "", "Print a machine-readable result instead of progress (json)")
 
 	rootCmd.AddCommand(convertCmd)
 }
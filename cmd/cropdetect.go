@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/felipekafuri/fk-converter/converter"
+	"github.com/spf13/cobra"
+)
+
+var cropDetectDuration time.Duration
+
+var cropDetectCmd = &cobra.Command{
+	Use:   "cropdetect <input-file>",
+	Short: "Suggest a crop that removes black bars",
+	Long: `Run ffmpeg's cropdetect over part of a video and print the suggested crop
+filter and the black bars it removes, without converting anything.
+
+The crop can be passed on with --vf, or to other tools.
+
+Examples:
+  fk-converter cropdetect movie.mkv
+  fk-converter convert movie.mkv --vf "$(fk-converter cropdetect movie.mkv -Q)"`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := converter.CheckFFmpeg(); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(stdout, "Detecting crop: %s\n\n", args[0])
+
+		d, err := converter.DetectCrop(args[0], cropDetectDuration)
+		if err != nil {
+			return err
+		}
+
+		if quiet {
+			fmt.Println(d.Crop.Filter())
+			return nil
+		}
+		fmt.Printf("Crop: %s\n", d.Crop.Filter())
+		fmt.Printf("Black bars: top %d, bottom %d, left %d, right %d (%dx%d → %dx%d)\n",
+			d.Top, d.Bottom, d.Left, d.Right, d.SourceWidth, d.SourceHeight, d.Crop.Width, d.Crop.Height)
+		return nil
+	},
+}
+
+func init() {
+	cropDetectCmd.Flags().DurationVar(&cropDetectDuration, "duration", 0, "How much of the video to analyze (default: 60s)")
+
+	rootCmd.AddCommand(cropDetectCmd)
+}
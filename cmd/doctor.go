@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/felipekafuri/fk-converter/converter"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check that ffmpeg and its encoders are available",
+	Long: `Check the environment fk-converter runs in: whether ffmpeg and ffprobe are
+found and which version, whether ffmpeg has every encoder fk-converter may
+use, and which hardware decoders it supports.
+
+Exits with an error if any check fails.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		failed := 0
+		for _, check := range converter.Doctor() {
+			mark := "✓"
+			if !check.OK {
+				mark = "✗"
+				failed++
+			}
+			fmt.Fprintf(stdout, "%s %s: %s\n", mark, check.Name, check.Detail)
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d check(s) failed", failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// fileModeFlag is a flag value holding permission bits written in octal,
+// like chmod's 0644.
+type fileModeFlag os.FileMode
+
+func (m *fileModeFlag) String() string {
+	if *m == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%04o", uint32(*m))
+}
+
+func (m *fileModeFlag) Set(s string) error {
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || mode > uint64(os.ModePerm) {
+		return fmt.Errorf("must be octal permissions, e.g. 0644")
+	}
+	*m = fileModeFlag(mode)
+	return nil
+}
+
+func (m *fileModeFlag) Type() string {
+	return "mode"
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/felipekafuri/fk-converter/converter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	framesOutput        string
+	framesStartNumber   int
+	framesKeyframesOnly bool
+	framesSampleFPS     float64
+	framesJPEGQuality   int
+)
+
+var framesCmd = &cobra.Command{
+	Use:   "frames <input-file>",
+	Short: "Export a video as an image sequence",
+	Long: `Export the frames of a video as numbered images.
+
+The output is a pattern with a zero-padded frame number, e.g.
+shots/frame_%04d.png (the default is name_%04d.png next to the input).
+
+Examples:
+  fk-converter frames clip.mp4 -o shots/frame_%06d.png --start-number 1001
+  fk-converter frames movie.mkv -o thumbs/kf_%04d.jpg --keyframes-only
+  fk-converter frames clip.mp4 -o dataset/clip_%06d.jpg --sample-fps 2 --jpeg-quality 3`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := converter.CheckFFmpeg(); err != nil {
+			return err
+		}
+
+		opts := &converter.FramesOptions{
+			Input:         args[0],
+			Output:        framesOutput,
+			StartNumber:   framesStartNumber,
+			KeyframesOnly: framesKeyframesOnly,
+			SampleFPS:     framesSampleFPS,
+			JPEGQuality:   framesJPEGQuality,
+		}
+
+		converter.ResolveFramesOutput(opts)
+		if err := converter.ValidateFramesOptions(opts); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(stdout, "Exporting frames: %s → %s\n", opts.Input, opts.Output)
+
+		bar := newProgressBar("Exporting")
+		start := time.Now()
+
+		files, err := converter.ExportFrames(opts, func(percent float64) {
+			bar.Set(int(percent))
+		})
+		if err != nil {
+			fmt.Fprintln(stdout)
+			return err
+		}
+
+		bar.Finish()
+		elapsed := time.Since(start).Round(time.Millisecond)
+
+		fmt.Fprintf(stdout, "\nDone in %s → %d images\n", elapsed, len(files))
+		return nil
+	},
+}
+
+func init() {
+	framesCmd.Flags().StringVarP(&framesOutput, "output", "o", "", "Output pattern with a frame number placeholder, e.g. frame_%04d.png")
+	framesCmd.Flags().IntVar(&framesStartNumber, "start-number", 0, "Number of the first image")
+	framesCmd.Flags().BoolVar(&framesKeyframesOnly, "keyframes-only", false, "Only export keyframes (I-frames)")
+	framesCmd.Flags().Float64Var(&framesSampleFPS, "sample-fps", 0, "Export N evenly spaced frames per second whatever the source frame rate, e.g. 1 or 0.5 (default output: name_%06d.jpg)")
+	framesCmd.Flags().IntVar(&framesJPEGQuality, "jpeg-quality", 0, "JPEG quality from 2 (best) to 31 (smallest) (default: ffmpeg's)")
+
+	rootCmd.AddCommand(framesCmd)
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/felipekafuri/fk-converter/converter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	onComplete string
+	onError    string
+)
+
+func addHookFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&onComplete, "on-complete", "", "Shell command to run after each successful conversion ($FK_INPUT, $FK_OUTPUT are set)")
+	cmd.Flags().StringVar(&onError, "on-error", "", "Shell command to run after each failed conversion ($FK_INPUT, $FK_OUTPUT, $FK_ERROR are set)")
+}
+
+// runHook runs --on-complete or --on-error, depending on convErr, with the
+// conversion's paths in its environment. The hook's output is passed
+// through; a failing hook is reported as an error.
+func runHook(opts *converter.Options, convErr error) error {
+	command := onComplete
+	if convErr != nil {
+		command = onError
+	}
+	if command == "" {
+		return nil
+	}
+
+	cmd := shellCommand(command)
+	cmd.Env = append(os.Environ(),
+		"FK_INPUT="+opts.Input,
+		"FK_OUTPUT="+opts.Output,
+	)
+	if convErr != nil {
+		cmd.Env = append(cmd.Env, "FK_ERROR="+convErr.Error())
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("hook %q failed: %w", command, err)
+	}
+	return nil
+}
+
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/felipekafuri/fk-converter/converter"
+)
+
+// collectJobs builds the conversions asked for by the input arguments and
+// --from-file, in that order.
+func collectJobs(args []string) ([]*converter.Options, error) {
+	inputs, err := expandInputs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []*converter.Options
+	for _, input := range inputs {
+		jobs = append(jobs, newOptions(input))
+	}
+	if fromFile != "" {
+		entries, err := readManifest(fromFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			opts := newOptions(e.input)
+			opts.Output = e.output
+			jobs = append(jobs, opts)
+		}
+	}
+
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("no input files given")
+	}
+	return jobs, nil
+}
+
+// expandInputs expands glob patterns in the input arguments, for shells
+// (like cmd.exe) that pass them through unexpanded. An argument naming an
+// existing file is taken literally even if it contains glob characters.
+func expandInputs(args []string) ([]string, error) {
+	var inputs []string
+	for _, arg := range args {
+		if !strings.ContainsAny(arg, "*?[") {
+			inputs = append(inputs, arg)
+			continue
+		}
+		if _, err := os.Stat(arg); err == nil {
+			inputs = append(inputs, arg)
+			continue
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %s", arg)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %s", arg)
+		}
+		inputs = append(inputs, matches...)
+	}
+	return inputs, nil
+}
+
+// createOutputDir creates --output-dir if it was given and doesn't exist.
+func createOutputDir() error {
+	if outputDir == "" {
+		return nil
+	}
+	return os.MkdirAll(outputDir, 0o755)
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/felipekafuri/fk-converter/converter"
+	"github.com/spf13/cobra"
+)
+
+var ladderRenditions string
+
+var ladderCmd = &cobra.Command{
+	Use:   "ladder <input-file>",
+	Short: "Encode several resolutions from one decode pass",
+	Long: `Encode several renditions of a video, e.g. for adaptive streaming.
+
+The input is decoded once and encoded into one file per rendition, named
+after the input with the resolution as suffix (video_720p.mp4, ...).
+
+Examples:
+  fk-converter ladder video.mov --renditions 1080p:6M,720p:3M,480p:1.5M
+  fk-converter ladder video.mov --renditions 720p:3M,360p:800k -f webm`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := converter.CheckFFmpeg(); err != nil {
+			return err
+		}
+
+		renditions, err := converter.ParseRenditions(ladderRenditions)
+		if err != nil {
+			return err
+		}
+
+		opts := newOptions(args[0])
+
+		fmt.Fprintf(stdout, "Encoding %d renditions of %s\n", len(renditions), opts.Input)
+
+		bar := newProgressBar("Encoding")
+		start := time.Now()
+
+		renditions, err = converter.ConvertLadder(opts, renditions, func(percent float64) {
+			bar.Set(int(percent))
+		})
+		if err != nil {
+			fmt.Fprintln(stdout)
+			return err
+		}
+
+		bar.Finish()
+		elapsed := time.Since(start).Round(time.Millisecond)
+
+		fmt.Fprintf(stdout, "\nDone in %s\n", elapsed)
+		printRenditions(renditions)
+		return nil
+	},
+}
+
+func printRenditions(renditions []converter.Rendition) {
+	for _, r := range renditions {
+		fmt.Fprintf(stdout, "  %-6s %-6s → %s\n", r.Resolution, r.Bitrate, r.Output)
+	}
+}
+
+func init() {
+	addConversionFlags(ladderCmd)
+	ladderCmd.Flags().StringVar(&ladderRenditions, "renditions", "1080p:6M,720p:3M,480p:1.5M", "Comma-separated resolution:bitrate list")
+
+	rootCmd.AddCommand(ladderCmd)
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var fromFile string
+
+// manifestEntry is one input listed in a --from-file manifest, with an
+// optional output path.
+type manifestEntry struct {
+	input  string
+	output string
+}
+
+// readManifest reads the inputs listed in path. A .csv manifest has the
+// input in the first column and an optional output in the second; any
+// other file lists one input per line. Blank lines and lines starting with
+// # are skipped. Inputs aren't checked here: a missing one fails as its own
+// job, so the batch still converts the others and reports it at the end.
+func readManifest(path string) ([]manifestEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input list: %w", err)
+	}
+	defer f.Close()
+
+	var entries []manifestEntry
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		entries, err = readCSVManifest(f)
+	} else {
+		entries, err = readTextManifest(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input list %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+func readTextManifest(r io.Reader) ([]manifestEntry, error) {
+	var entries []manifestEntry
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, manifestEntry{input: line})
+	}
+	return entries, scanner.Err()
+}
+
+func readCSVManifest(r io.Reader) ([]manifestEntry, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var entries []manifestEntry
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) > 2 {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("line %d: expected input[,output], got %d columns", line, len(record))
+		}
+		e := manifestEntry{input: strings.TrimSpace(record[0])}
+		if e.input == "" {
+			continue
+		}
+		if len(record) == 2 {
+			e.output = strings.TrimSpace(record[1])
+		}
+		entries = append(entries, e)
+	}
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+var preview bool
+
+// openerCommand returns the command that opens a file in the system's
+// default application.
+func openerCommand(path string) *exec.Cmd {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", path)
+	case "windows":
+		// start's first quoted argument is the window title.
+		return exec.Command("cmd", "/C", "start", "", path)
+	default:
+		return exec.Command("xdg-open", path)
+	}
+}
+
+// previewFile opens path in the default player, or in ffplay when there is
+// none, without waiting for it to close. A missing player is only reported.
+func previewFile(path string) {
+	cmd := openerCommand(path)
+	if cmd.Err != nil {
+		cmd = exec.Command("ffplay", "-autoexit", "-loglevel", "error", path)
+	}
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "Can't preview %s: no player found (install ffplay or set a default video player)\n", path)
+		return
+	}
+	cmd.Process.Release()
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/felipekafuri/fk-converter/converter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	previewsOutput     string
+	previewsInterval   time.Duration
+	previewsThumbWidth int
+	previewsColumns    int
+	previewsRows       int
+)
+
+var previewsCmd = &cobra.Command{
+	Use:   "previews <input-file>",
+	Short: "Generate scrub bar thumbnails (sprites + WebVTT) for web players",
+	Long: `Generate the thumbnail previews web players show when hovering the scrub
+bar: sprite sheets of frames taken at an interval, and a WebVTT file mapping
+each stretch of time to its thumbnail.
+
+The sprites are written next to the VTT file and named after it
+(video_previews_001.jpg, ...).
+
+Examples:
+  fk-converter previews video.mp4
+  fk-converter previews video.mp4 -o public/thumbs.vtt --interval 5s --thumb-width 240`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := converter.CheckFFmpeg(); err != nil {
+			return err
+		}
+
+		opts := &converter.PreviewsOptions{
+			Input:      args[0],
+			Output:     previewsOutput,
+			Interval:   previewsInterval,
+			ThumbWidth: previewsThumbWidth,
+			Columns:    previewsColumns,
+			Rows:       previewsRows,
+		}
+
+		converter.ResolvePreviewsOptions(opts)
+		if err := converter.ValidatePreviewsOptions(opts); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(stdout, "Generating previews: %s → %s (every %s)\n", opts.Input, opts.Output, opts.Interval)
+
+		bar := newProgressBar("Generating")
+		start := time.Now()
+
+		previews, err := converter.GeneratePreviews(opts, func(percent float64) {
+			bar.Set(int(percent))
+		})
+		if err != nil {
+			fmt.Fprintln(stdout)
+			return err
+		}
+
+		bar.Finish()
+		elapsed := time.Since(start).Round(time.Millisecond)
+
+		fmt.Fprintf(stdout, "\nDone in %s → %s (%d sprite sheets)\n", elapsed, previews.VTT, len(previews.Sprites))
+		return nil
+	},
+}
+
+func init() {
+	previewsCmd.Flags().StringVarP(&previewsOutput, "output", "o", "", "Output VTT file (default: name_previews.vtt)")
+	previewsCmd.Flags().DurationVar(&previewsInterval, "interval", 0, "Time between thumbnails (default: 10s)")
+	previewsCmd.Flags().IntVar(&previewsThumbWidth, "thumb-width", 0, "Thumbnail width in pixels (default: 160)")
+	previewsCmd.Flags().IntVar(&previewsColumns, "columns", 0, "Thumbnails per sprite row (default: 10)")
+	previewsCmd.Flags().IntVar(&previewsRows, "rows", 0, "Thumbnail rows per sprite (default: 10)")
+
+	rootCmd.AddCommand(previewsCmd)
+}
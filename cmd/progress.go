@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+var (
+	progressStyle string
+	progressWidth int
+	noProgress    bool
+)
+
+// progressDisplay shows the progress of one task, in percent, in the style
+// chosen with --progress. *progressbar.ProgressBar is the default one.
+type progressDisplay interface {
+	Set(percent int) error
+	Describe(description string)
+	Finish() error
+}
+
+func validateProgressStyle() error {
+	if noProgress {
+		progressStyle = "none"
+	}
+	switch progressStyle {
+	case "bar", "simple", "none":
+	default:
+		return fmt.Errorf("unsupported progress style: %s (supported: bar, simple, none)", progressStyle)
+	}
+	if progressWidth <= 0 {
+		return fmt.Errorf("invalid progress width: %d (must be positive)", progressWidth)
+	}
+	return nil
+}
+
+func newProgressBar(description string) progressDisplay {
+	switch progressStyle {
+	case "simple":
+		return &simpleProgress{w: stdout, description: description}
+	case "none":
+		return noopProgress{}
+	}
+	return progressbar.NewOptions(100,
+		progressbar.OptionSetWriter(stdout),
+		progressbar.OptionSetDescription(description),
+		progressbar.OptionSetWidth(progressWidth),
+		progressbar.OptionShowBytes(false),
+		progressbar.OptionSetPredictTime(false),
+		progressbar.OptionThrottle(100*time.Millisecond),
+		progressbar.OptionShowCount(),
+		progressbar.OptionClearOnFinish(),
+	)
+}
+
+// simpleProgress prints a plain line every 10%, without the terminal
+// control codes of the bar, for logs and other non-terminal output.
+type simpleProgress struct {
+	w           io.Writer
+	description string
+	printed     int
+}
+
+func (p *simpleProgress) Set(percent int) error {
+	if step := percent / 10 * 10; step > p.printed {
+		p.printed = step
+		fmt.Fprintf(p.w, "%s: %d%%\n", p.description, step)
+	}
+	return nil
+}
+
+func (p *simpleProgress) Describe(description string) {
+	p.description = description
+}
+
+func (p *simpleProgress) Finish() error {
+	return p.Set(100)
+}
+
+type noopProgress struct{}
+
+func (noopProgress) Set(int) error   { return nil }
+func (noopProgress) Describe(string) {}
+func (noopProgress) Finish() error   { return nil }
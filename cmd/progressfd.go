@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/felipekafuri/fk-converter/converter"
+)
+
+var progressFD int
+
+// progressEvent is one line written to --progress-fd. Like ffmpeg's own
+// -progress output, State is "continue" until the file is finished, then
+// "end".
+type progressEvent struct {
+	Input   string  `json:"input"`
+	State   string  `json:"state"`
+	Percent float64 `json:"percent"`
+	Frame   int64   `json:"frame,omitempty"`
+	FPS     float64 `json:"fps,omitempty"`
+	Bitrate float64 `json:"bitrate_kbps,omitempty"`
+	Speed   float64 `json:"speed,omitempty"`
+	ETA     float64 `json:"eta_seconds,omitempty"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// progressWriter sends newline-delimited JSON progress to a file descriptor
+// inherited from the parent process, for GUI frontends. A nil
+// *progressWriter discards everything.
+type progressWriter struct {
+	enc *json.Encoder
+}
+
+func openProgressFD() (*progressWriter, error) {
+	if progressFD <= 0 {
+		return nil, nil
+	}
+	f := os.NewFile(uintptr(progressFD), "progress-fd")
+	if _, err := f.Stat(); err != nil {
+		return nil, fmt.Errorf("--progress-fd %d is not an open file descriptor", progressFD)
+	}
+	return &progressWriter{enc: json.NewEncoder(f)}, nil
+}
+
+func (w *progressWriter) report(input string, p converter.Progress) {
+	if w == nil {
+		return
+	}
+	w.enc.Encode(progressEvent{
+		Input:   input,
+		State:   "continue",
+		Percent: p.Percent,
+		Frame:   p.Frame,
+		FPS:     p.FPS,
+		Bitrate: p.Bitrate,
+		Speed:   p.Speed,
+		ETA:     p.ETA.Seconds(),
+	})
+}
+
+func (w *progressWriter) end(input string, err error) {
+	if w == nil {
+		return
+	}
+	event := progressEvent{Input: input, State: "end", Percent: 100}
+	if err != nil {
+		event.Percent = 0
+		event.Error = err.Error()
+	}
+	w.enc.Encode(event)
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// rawArgsFlag is a flag value holding extra ffmpeg arguments, given as a
+// single shell-quoted string.
+type rawArgsFlag []string
+
+func (a *rawArgsFlag) String() string {
+	return strings.Join(*a, " ")
+}
+
+func (a *rawArgsFlag) Set(s string) error {
+	args, err := splitRawArgs(s)
+	if err != nil {
+		return err
+	}
+	*a = args
+	return nil
+}
+
+func (a *rawArgsFlag) Type() string {
+	return "args"
+}
+
+// splitRawArgs splits --raw-args into arguments like a POSIX shell would:
+// on whitespace, honoring single and double quotes and backslash escapes.
+func splitRawArgs(s string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
@@ -2,15 +2,35 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/spf13/cobra"
 )
 
+var quiet bool
+
+// stdout receives progress bars, summaries and other chatter. --quiet
+// discards it so only errors (on stderr) remain.
+var stdout io.Writer = os.Stdout
+
 var rootCmd = &cobra.Command{
 	Use:   "fk-converter",
 	Short: "A fast video converter powered by ffmpeg",
 	Long:  "fk-converter converts video files between formats with quality control.\nIt wraps ffmpeg with sensible defaults and a progress bar.",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if quiet {
+			stdout = io.Discard
+		}
+		return validateProgressStyle()
+	},
+}
+
+func init() {
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "Q", false, "Print nothing but errors")
+	rootCmd.PersistentFlags().StringVar(&progressStyle, "progress", "bar", "Progress display: bar, simple (a plain line every 10%, for logs) or none")
+	rootCmd.PersistentFlags().IntVar(&progressWidth, "progress-width", 40, "Width of the progress bar in characters")
+	rootCmd.PersistentFlags().BoolVar(&noProgress, "no-progress", false, "Hide progress (same as --progress none)")
 }
 
 func Execute() {
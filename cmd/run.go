@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"github.com/felipekafuri/fk-converter/converter"
+	"github.com/spf13/cobra"
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run <job-file>...",
+	Short: "Convert as described by JSON or YAML job specs",
+	Long: `Run the conversions described in job spec files, for elaborate
+conversions that are easier to keep in a file (and in version control)
+than on a long command line.
+
+A job spec is a JSON or YAML (.yaml, .yml) object of conversion options,
+or a list of them. Keys are the library's Options fields, ignoring case,
+dashes and underscores: input, output, codec, quality, resolution,
+customVideoFilter, start, sampleDuration, mapAll, subtitles, ...
+Durations are written like "1m30s" and fileMode in octal, e.g. "0644".
+With renditions, the job encodes several sizes from one decode like the
+ladder command; give a spec such as "1080p:6M,720p:3M" or a list of
+resolution/bitrate/output objects.
+
+Example job.yaml:
+  input: lecture.mov
+  output: lecture.mp4
+  codec: h265
+  quality: high
+  resolution: 1080p
+  start: 1m30s
+  custom-video-filter: hqdn3d
+  target-lufs: -16
+  subtitles:
+    - path: lecture.srt
+      language: eng
+
+Examples:
+  fk-converter run job.yaml
+  fk-converter run jobs/*.json --fail-fast`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := converter.CheckFFmpeg(); err != nil {
+			return err
+		}
+
+		var jobs []*converter.Options
+		for _, path := range args {
+			loaded, err := converter.LoadJobs(path)
+			if err != nil {
+				return err
+			}
+			jobs = append(jobs, loaded...)
+		}
+
+		if len(jobs) > 1 {
+			return runBatch(jobs)
+		}
+		return runSingle(jobs[0])
+	},
+}
+
+func init() {
+	runCmd.Flags().BoolVar(&skipExisting, "skip-existing", false, "Skip jobs whose output already exists and is not older than the input (like make)")
+	runCmd.Flags().BoolVar(&batchKeepGoing, "keep-going", true, "Continue with the remaining jobs after a failure")
+	runCmd.Flags().BoolVar(&batchFailFast, "fail-fast", false, "Stop at the first failed job")
+	runCmd.MarkFlagsMutuallyExclusive("keep-going", "fail-fast")
+	addHookFlags(runCmd)
+
+	rootCmd.AddCommand(runCmd)
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/felipekafuri/fk-converter/converter"
+	"github.com/spf13/cobra"
+)
+
+var scenesThreshold float64
+
+var scenesCmd = &cobra.Command{
+	Use:   "scenes <input-file>",
+	Short: "List the scene changes of a video",
+	Long: `Run ffmpeg's scene change detection over a video and print the time of
+every cut, without converting anything.
+
+A frame starts a new scene when its scene score (0-1) is above
+--threshold; lower it to find subtler cuts. With -Q only the times are
+printed, in seconds, one per line.
+
+Examples:
+  fk-converter scenes movie.mkv
+  fk-converter scenes interview.mp4 --threshold 0.25 -Q > cuts.txt`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := converter.CheckFFmpeg(); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(stdout, "Detecting scenes: %s\n\n", args[0])
+
+		scenes, err := converter.DetectScenes(args[0], scenesThreshold)
+		if err != nil {
+			return err
+		}
+
+		if quiet {
+			for _, t := range scenes {
+				fmt.Printf("%.3f\n", t.Seconds())
+			}
+			return nil
+		}
+		for i, t := range scenes {
+			fmt.Printf("%4d  %s\n", i+1, clockTime(t))
+		}
+		fmt.Printf("\n%d scene changes\n", len(scenes))
+		return nil
+	},
+}
+
+// clockTime formats d as HH:MM:SS.mmm, as editors show timecodes.
+func clockTime(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+func init() {
+	scenesCmd.Flags().Float64Var(&scenesThreshold, "threshold", converter.DefaultSceneThreshold, "Scene score from 0 to 1 above which a frame starts a new scene")
+
+	rootCmd.AddCommand(scenesCmd)
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var sizeRegex = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*([kKmMgG]?)i?[bB]?$`)
+
+var sizeUnits = map[string]float64{
+	"":  1,
+	"k": 1 << 10,
+	"m": 1 << 20,
+	"g": 1 << 30,
+}
+
+// sizeFlag is a flag value holding a file size written like 50M, 1.5GB or
+// 800k. Units are binary, like the MB printed after converting.
+type sizeFlag int64
+
+func (s *sizeFlag) String() string {
+	if *s == 0 {
+		return ""
+	}
+	return strconv.FormatInt(int64(*s), 10)
+}
+
+func (s *sizeFlag) Set(value string) error {
+	m := sizeRegex.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil {
+		return fmt.Errorf("must be a size, e.g. 50M, 1.5G or 800k")
+	}
+	n, _ := strconv.ParseFloat(m[1], 64)
+	*s = sizeFlag(n * sizeUnits[strings.ToLower(m[2])])
+	return nil
+}
+
+func (s *sizeFlag) Type() string {
+	return "size"
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/felipekafuri/fk-converter/converter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	splitSegmentTime int
+	splitParts       int
+
+	splitBySilence        bool
+	splitSilenceThreshold float64
+	splitMinSilence       time.Duration
+)
+
+var splitCmd = &cobra.Command{
+	Use:   "split <input-file>",
+	Short: "Split a video into segments",
+	Long: `Split a video into numbered segments without re-encoding.
+
+Segments are written next to the input as name_001.ext, name_002.ext, ...
+Cuts snap to the nearest keyframe, so segment lengths are approximate.
+--segment-by-silence cuts in the middle of pauses instead.
+
+Examples:
+  fk-converter split lecture.mp4 --segment-time 600
+  fk-converter split movie.mkv --parts 4
+  fk-converter split interview.mp4 --segment-by-silence --min-silence 3s`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := converter.CheckFFmpeg(); err != nil {
+			return err
+		}
+
+		opts := &converter.SplitOptions{
+			Input:       args[0],
+			SegmentTime: time.Duration(splitSegmentTime) * time.Second,
+			Parts:       splitParts,
+
+			BySilence:        splitBySilence,
+			SilenceThreshold: splitSilenceThreshold,
+			MinSilence:       splitMinSilence,
+		}
+
+		if err := converter.ValidateSplitOptions(opts); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(stdout, "Splitting: %s\n", opts.Input)
+
+		bar := newProgressBar("Splitting")
+		start := time.Now()
+
+		files, err := converter.Split(opts, func(percent float64) {
+			bar.Set(int(percent))
+		})
+		if err != nil {
+			fmt.Fprintln(stdout)
+			return err
+		}
+
+		bar.Finish()
+		elapsed := time.Since(start).Round(time.Millisecond)
+
+		fmt.Fprintf(stdout, "\nDone in %s → %d segments\n", elapsed, len(files))
+		for _, f := range files {
+			fmt.Fprintf(stdout, "  %s\n", f)
+		}
+		return nil
+	},
+}
+
+func init() {
+	splitCmd.Flags().IntVar(&splitSegmentTime, "segment-time", 0, "Segment length in seconds")
+	splitCmd.Flags().IntVar(&splitParts, "parts", 0, "Number of equal-length parts")
+	splitCmd.Flags().BoolVar(&splitBySilence, "segment-by-silence", false, "Cut at pauses in the audio")
+	splitCmd.Flags().Float64Var(&splitSilenceThreshold, "silence-threshold", -30, "Volume in dB below which audio counts as silence")
+	splitCmd.Flags().DurationVar(&splitMinSilence, "min-silence", 2*time.Second, "Shortest pause to cut at")
+
+	rootCmd.AddCommand(splitCmd)
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/felipekafuri/fk-converter/converter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	subtitleTrack  int
+	subtitleOutput string
+)
+
+var subtitlesCmd = &cobra.Command{
+	Use:   "subtitles <input-file>",
+	Short: "List or extract embedded subtitle tracks",
+	Long: `List the subtitle tracks embedded in a video, or extract one to a file.
+
+Without --output the tracks are listed. With --output the selected track is
+converted to the format given by the extension (srt, vtt, ass).
+
+Examples:
+  fk-converter subtitles movie.mkv
+  fk-converter subtitles movie.mkv --track 1 --output subs.srt`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := converter.CheckFFmpeg(); err != nil {
+			return err
+		}
+
+		input := args[0]
+
+		if subtitleOutput == "" {
+			info, err := converter.Probe(input)
+			if err != nil {
+				return err
+			}
+			tracks := info.StreamsOfType("subtitle")
+			if len(tracks) == 0 {
+				fmt.Printf("%s has no subtitle tracks\n", input)
+				return nil
+			}
+			for i, t := range tracks {
+				lang := t.Language
+				if lang == "" {
+					lang = "und"
+				}
+				fmt.Printf("  %d  %-4s %s", i, lang, t.Codec)
+				if t.Title != "" {
+					fmt.Printf("  %s", t.Title)
+				}
+				fmt.Println()
+			}
+			return nil
+		}
+
+		if err := converter.ExtractSubtitle(input, subtitleTrack, subtitleOutput); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Subtitle track %d → %s\n", subtitleTrack, subtitleOutput)
+		return nil
+	},
+}
+
+// parseSubtitleFiles splits --subtitles values of the form file[:lang].
+// A colon followed by a path, as in C:\subs.srt, isn't a language.
+func parseSubtitleFiles(values []string) []converter.SubtitleTrack {
+	var tracks []converter.SubtitleTrack
+	for _, v := range values {
+		track := converter.SubtitleTrack{Path: v}
+		if i := strings.LastIndex(v, ":"); i > 0 && !strings.ContainsAny(v[i+1:], `./\`) {
+			track.Path, track.Language = v[:i], v[i+1:]
+		}
+		tracks = append(tracks, track)
+	}
+	return tracks
+}
+
+func init() {
+	subtitlesCmd.Flags().IntVar(&subtitleTrack, "track", 0, "Subtitle track index to extract")
+	subtitlesCmd.Flags().StringVarP(&subtitleOutput, "output", "o", "", "Output subtitle file (srt, vtt, ass)")
+
+	rootCmd.AddCommand(subtitlesCmd)
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/felipekafuri/fk-converter/converter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	waveformOutput string
+	waveformWidth  int
+	waveformHeight int
+	waveformColor  string
+)
+
+var waveformCmd = &cobra.Command{
+	Use:   "waveform <input-file>",
+	Short: "Render the audio waveform to an image",
+	Long: `Render the waveform of a file's audio (the first track, mixed to mono)
+to a single image, e.g. as a preview for podcast episodes.
+
+Examples:
+  fk-converter waveform episode.mp3
+  fk-converter waveform interview.mp4 -o wave.png --width 1200 --height 120 --color white`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := converter.CheckFFmpeg(); err != nil {
+			return err
+		}
+
+		opts := &converter.WaveformOptions{
+			Input:  args[0],
+			Output: waveformOutput,
+			Width:  waveformWidth,
+			Height: waveformHeight,
+			Color:  waveformColor,
+		}
+
+		converter.ResolveWaveformOptions(opts)
+		if err := converter.ValidateWaveformOptions(opts); err != nil {
+			return err
+		}
+
+		if err := converter.Waveform(opts); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(stdout, "Waveform: %s → %s (%dx%d)\n", opts.Input, opts.Output, opts.Width, opts.Height)
+		return nil
+	},
+}
+
+func init() {
+	waveformCmd.Flags().StringVarP(&waveformOutput, "output", "o", "", "Output image (default: name_waveform.png)")
+	waveformCmd.Flags().IntVar(&waveformWidth, "width", 0, "Image width in pixels (default: 1920)")
+	waveformCmd.Flags().IntVar(&waveformHeight, "height", 0, "Image height in pixels (default: 240)")
+	waveformCmd.Flags().StringVar(&waveformColor, "color", "", "Waveform color, a name or #RRGGBB (default: #3b82f6)")
+
+	rootCmd.AddCommand(waveformCmd)
+}
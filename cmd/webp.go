@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/felipekafuri/fk-converter/converter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	webpOutput  string
+	webpFPS     float64
+	webpQuality int
+	webpLoop    int
+)
+
+var webpCmd = &cobra.Command{
+	Use:   "webp <input-file>",
+	Short: "Turn a short clip into an animated WebP",
+	Long: `Encode a video as an animated WebP, a much smaller alternative to GIF
+at similar quality for short looping clips. The audio is dropped.
+
+The ffmpeg build needs libwebp (the libwebp_anim encoder).
+
+Examples:
+  fk-converter webp clip.mp4
+  fk-converter webp reaction.mov -o reaction.webp --fps 12 --quality 60 --loop 3`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := converter.CheckFFmpeg(); err != nil {
+			return err
+		}
+
+		opts := &converter.WebPOptions{
+			Input:   args[0],
+			Output:  webpOutput,
+			FPS:     webpFPS,
+			Quality: webpQuality,
+			Loop:    webpLoop,
+		}
+
+		converter.ResolveWebPOptions(opts)
+		if err := converter.ValidateWebPOptions(opts); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(stdout, "Animating: %s → %s (%g fps, quality %d)\n", opts.Input, opts.Output, opts.FPS, opts.Quality)
+
+		bar := newProgressBar("Encoding")
+		start := time.Now()
+
+		err := converter.AnimatedWebP(opts, func(percent float64) {
+			bar.Set(int(percent))
+		})
+		if err != nil {
+			fmt.Fprintln(stdout)
+			return err
+		}
+
+		bar.Finish()
+		elapsed := time.Since(start).Round(time.Millisecond)
+
+		fmt.Fprintf(stdout, "\nDone in %s → %s\n", elapsed, opts.Output)
+		return nil
+	},
+}
+
+func init() {
+	webpCmd.Flags().StringVarP(&webpOutput, "output", "o", "", "Output file (default: name.webp)")
+	webpCmd.Flags().Float64Var(&webpFPS, "fps", 0, "Frame rate of the animation (default: 15)")
+	webpCmd.Flags().IntVar(&webpQuality, "quality", 0, "Quality from 0 to 100, higher is better and larger (default: 75)")
+	webpCmd.Flags().IntVar(&webpLoop, "loop", 0, "Loop count stored in the animation, 0 loops forever")
+
+	rootCmd.AddCommand(webpCmd)
+}
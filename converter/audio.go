@@ -0,0 +1,180 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// losslessAudioCodecs is the audio encoder each container gets with the
+// lossless quality preset. webm has no lossless audio codec and keeps the
+// regular lossy encoding.
+var losslessAudioCodecs = map[string]string{
+	"mkv": "flac",
+	"mp4": "alac",
+	"mov": "alac",
+	"avi": "pcm_s16le",
+}
+
+// containerAudioCodecs lists the source audio codecs each container can
+// hold as they are, so they can be copied instead of re-encoded.
+var containerAudioCodecs = map[string][]string{
+	"mp4":  {"aac", "mp3", "alac", "ac3", "eac3"},
+	"mov":  {"aac", "mp3", "alac", "ac3", "eac3", "pcm_s16le", "pcm_s24le"},
+	"mkv":  {"aac", "mp3", "opus", "vorbis", "flac", "alac", "ac3", "eac3", "dts", "truehd", "pcm_s16le", "pcm_s24le"},
+	"webm": {"opus", "vorbis"},
+	"avi":  {"mp3", "ac3", "pcm_s16le"},
+}
+
+// canCopyAudio reports whether the input's audio can go into the output
+// untouched: every audio stream is in a codec the container holds and
+// nothing asks to change the audio.
+func canCopyAudio(opts *Options, src *ProbeInfo) bool {
+	if opts.NoAudioCopy || src == nil || opts.Quality == QualityLossless {
+		return false
+	}
+	if opts.Audio != "" || opts.ReplaceAudio != "" || opts.BackgroundAudio != "" ||
+		opts.SampleRate > 0 || opts.AudioBitrate != "" || len(buildAudioFilters(opts, src)) > 0 {
+		return false
+	}
+	streams := src.StreamsOfType("audio")
+	if len(streams) == 0 {
+		return false
+	}
+	for _, s := range streams {
+		if !slices.Contains(containerAudioCodecs[opts.Format], s.Codec) {
+			return false
+		}
+	}
+	return true
+}
+
+// audioCodecArgs selects the audio encoder for the main output, copying
+// compatible source audio. Lossless encoders take no bitrate; lossy ones
+// get the quality preset's bitrate unless AudioBitrate overrides it.
+func audioCodecArgs(opts *Options, src *ProbeInfo) []string {
+	if canCopyAudio(opts, src) {
+		return []string{"-c:a", "copy"}
+	}
+	if opts.Quality == QualityLossless {
+		if codec, ok := losslessAudioCodecs[opts.Format]; ok {
+			return []string{"-c:a", codec}
+		}
+	}
+	bitrate := opts.AudioBitrate
+	if bitrate == "" {
+		bitrate = audioBitrateMap[opts.Quality]
+	}
+	return []string{"-c:a", lossyAudioCodec(opts.Format), "-b:a", bitrate}
+}
+
+// lossyAudioCodec is the audio encoder for lossy output: AAC, except in
+// webm, which only holds Opus and Vorbis.
+func lossyAudioCodec(format string) string {
+	if format == "webm" {
+		return "libopus"
+	}
+	return "aac"
+}
+
+// extractAudioFormat returns the encoder for --extract-audio. m4a holds
+// ALAC as well as AAC, so lossless quality switches to it.
+func extractAudioFormat(opts *Options) audioFormat {
+	ext := getExtension(opts.ExtractAudio)
+	if opts.Quality == QualityLossless && ext == "m4a" {
+		return audioFormat{"alac", ""}
+	}
+	return audioFormats[ext]
+}
+
+// defaultBackgroundVolume keeps background music under speech.
+const defaultBackgroundVolume = 0.25
+
+// backgroundAudioArgs mixes the looped BackgroundAudio (input 1) under the
+// input's own audio, or uses it alone for silent inputs. The generated and
+// custom audio filters run inside the same graph, since -af can't be
+// combined with -filter_complex outputs.
+func backgroundAudioArgs(opts *Options, src *ProbeInfo) []string {
+	volume := opts.BackgroundVolume
+	if volume == 0 {
+		volume = defaultBackgroundVolume
+	}
+	graph := "[1:a]volume=" + strconv.FormatFloat(volume, 'f', -1, 64)
+
+	// Without a probe, assume the input has audio; ffmpeg reports it if not.
+	silent := src != nil && len(src.StreamsOfType("audio")) == 0
+	if !silent {
+		graph += "[bg];[0:a:0][bg]amix=inputs=2:duration=first:dropout_transition=0:normalize=0"
+	}
+	if filters := buildAudioFilters(opts, src); len(filters) > 0 {
+		graph += "," + strings.Join(filters, ",")
+	}
+	graph += "[a]"
+
+	args := []string{"-filter_complex", graph, "-map", "0:v:0", "-map", "[a]"}
+	if silent {
+		// The music loops forever; stop at the end of the video.
+		args = append(args, "-shortest")
+	}
+	return args
+}
+
+func validateBackgroundAudio(opts *Options) error {
+	if _, err := os.Stat(opts.BackgroundAudio); os.IsNotExist(err) {
+		return fmt.Errorf("background audio file does not exist: %s", opts.BackgroundAudio)
+	}
+	if opts.BackgroundVolume < 0 {
+		return fmt.Errorf("invalid background volume: %g (must be 0 or greater)", opts.BackgroundVolume)
+	}
+	if opts.ReplaceAudio != "" {
+		return fmt.Errorf("--background-audio cannot be combined with --replace-audio")
+	}
+	if opts.MapAll {
+		return fmt.Errorf("--background-audio cannot be combined with --map-all")
+	}
+	return nil
+}
+
+// audioChannels returns the channel count of the first audio stream, or 0
+// if unknown.
+func audioChannels(src *ProbeInfo) int {
+	if src == nil {
+		return 0
+	}
+	if streams := src.StreamsOfType("audio"); len(streams) > 0 {
+		return streams[0].Channels
+	}
+	return 0
+}
+
+// sourceSampleRate returns the sample rate of the first audio stream, or 0
+// if unknown.
+func sourceSampleRate(src *ProbeInfo) int {
+	if src == nil {
+		return 0
+	}
+	if streams := src.StreamsOfType("audio"); len(streams) > 0 {
+		return streams[0].SampleRate
+	}
+	return 0
+}
+
+// downmixFilter mixes surround audio to stereo. Both 5.1 layouts start
+// FL FR FC LFE, followed by the surround pairs, so channels are addressed
+// by position. The center (dialog) goes to both sides at -3 dB and the
+// surrounds to their side; LFE is dropped, as in the ITU downmix. Unknown
+// layouts fall back to ffmpeg's default downmix; stereo and mono are left
+// alone.
+func downmixFilter(src *ProbeInfo) string {
+	switch audioChannels(src) {
+	case 1, 2:
+		return ""
+	case 6:
+		return "pan=stereo|FL=0.5*c0+0.707*c2+0.5*c4|FR=0.5*c1+0.707*c2+0.5*c5"
+	case 8:
+		return "pan=stereo|FL=0.5*c0+0.707*c2+0.35*c4+0.35*c6|FR=0.5*c1+0.707*c2+0.35*c5+0.35*c7"
+	}
+	return "aformat=channel_layouts=stereo"
+}
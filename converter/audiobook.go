@@ -0,0 +1,147 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// AudiobookOptions describes an audio-only m4a or m4b (audiobook) file made
+// from the first audio track of the input, with optional chapters and
+// cover art.
+type AudiobookOptions struct {
+	Input  string
+	Output string
+
+	// Chapters is a chapters file as for Options.Chapters.
+	Chapters string
+
+	// CoverArt is a jpg or png image shown by players.
+	CoverArt string
+
+	// Bitrate is the AAC bitrate (default 64k, plenty for speech). An AAC
+	// input is copied unless a bitrate is given.
+	Bitrate string
+
+	// Title and Author are written to the title and artist tags.
+	Title  string
+	Author string
+}
+
+const defaultAudiobookBitrate = "64k"
+
+// audiobookFormats are the extensions written with the ipod muxer, which
+// only holds AAC (and ALAC) audio.
+var audiobookFormats = map[string]bool{
+	"m4a": true,
+	"m4b": true,
+}
+
+func Audiobook(opts *AudiobookOptions, onProgress ProgressFunc) error {
+	return defaultConverter.Audiobook(context.Background(), opts, onProgress)
+}
+
+// ResolveAudiobookOptions names the output name.m4b next to the input.
+func ResolveAudiobookOptions(opts *AudiobookOptions) {
+	if opts.Output == "" {
+		opts.Output = trimExtension(opts.Input) + ".m4b"
+	}
+}
+
+// ValidateAudiobookOptions checks opts before running. Every error it
+// returns matches ErrInvalidOptions.
+func ValidateAudiobookOptions(opts *AudiobookOptions) error {
+	return invalidOptions(validateAudiobookOptions(opts))
+}
+
+func validateAudiobookOptions(opts *AudiobookOptions) error {
+	if _, err := os.Stat(opts.Input); os.IsNotExist(err) {
+		return fmt.Errorf("input file does not exist: %s", opts.Input)
+	}
+	if ext := getExtension(opts.Output); !audiobookFormats[ext] {
+		return fmt.Errorf("unsupported audiobook format: %s (supported: m4a, m4b)", ext)
+	}
+	if opts.Chapters != "" {
+		if err := validateChaptersFile(opts.Chapters); err != nil {
+			return err
+		}
+	}
+	if opts.CoverArt != "" {
+		if err := validateCoverArt(opts.CoverArt); err != nil {
+			return err
+		}
+	}
+	if opts.Bitrate != "" && !bitrateRegex.MatchString(opts.Bitrate) {
+		return fmt.Errorf("invalid audio bitrate: %s (examples: 64k, 128k)", opts.Bitrate)
+	}
+	return nil
+}
+
+// Audiobook writes the input's first audio track as AAC in an m4a/m4b
+// file, with chapters for navigation and the cover art as an attached
+// picture.
+func (c *Converter) Audiobook(ctx context.Context, opts *AudiobookOptions, onProgress ProgressFunc) error {
+	ResolveAudiobookOptions(opts)
+	if err := ValidateAudiobookOptions(opts); err != nil {
+		return err
+	}
+
+	info, err := c.Probe(opts.Input)
+	if err != nil {
+		return fmt.Errorf("cannot read input %s, it may be corrupt or not a media file: %w", opts.Input, err)
+	}
+	audio := info.StreamsOfType("audio")
+	if len(audio) == 0 {
+		return fmt.Errorf("input has no audio: %s", opts.Input)
+	}
+
+	temps := &cleanup{}
+	defer temps.removeAll()
+
+	args := []string{"-i", opts.Input}
+	next := 1
+	chapterInput, coverInput := -1, -1
+	if opts.Chapters != "" {
+		meta, err := writeChapterMetadata(opts.Chapters, info.Duration, temps)
+		if err != nil {
+			return err
+		}
+		args = append(args, "-i", meta)
+		chapterInput, next = next, next+1
+	}
+	if opts.CoverArt != "" {
+		args = append(args, "-i", opts.CoverArt)
+		coverInput = next
+	}
+	args = append(args, "-y", "-progress", "pipe:2", "-nostats", "-map", "0:a:0")
+
+	if audio[0].Codec == "aac" && opts.Bitrate == "" {
+		args = append(args, "-c:a", "copy")
+	} else {
+		bitrate := opts.Bitrate
+		if bitrate == "" {
+			bitrate = defaultAudiobookBitrate
+		}
+		args = append(args, "-c:a", "aac", "-b:a", bitrate)
+	}
+
+	if coverInput >= 0 {
+		args = append(args, "-map", strconv.Itoa(coverInput)+":v:0", "-c:v", "copy", "-disposition:v:0", "attached_pic")
+	}
+	if chapterInput >= 0 {
+		args = append(args, "-map_chapters", strconv.Itoa(chapterInput))
+	}
+	if opts.Title != "" {
+		args = append(args, "-metadata", "title="+opts.Title)
+	}
+	if opts.Author != "" {
+		args = append(args, "-metadata", "artist="+opts.Author)
+	}
+	args = append(args, "-movflags", "+faststart", "-f", "ipod", opts.Output)
+
+	if err := c.runFFmpeg(ctx, args, progressTotal{duration: info.Duration}, progressReporter(onProgress, nil)); err != nil {
+		return fmt.Errorf("ffmpeg audiobook encoding failed: %w", err)
+	}
+	return nil
+}
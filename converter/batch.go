@@ -0,0 +1,124 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Batch describes a sequence of conversions run with shared error handling.
+// The hooks are optional and are called with the job's index in Jobs.
+type Batch struct {
+	Jobs []*Options
+
+	// FailFast stops at the first failure instead of continuing with the
+	// remaining jobs.
+	FailFast bool
+
+	// SkipUpToDate skips jobs whose output is up to date (see UpToDate),
+	// so re-running a batch over a growing directory only converts new and
+	// changed files. OnSkip is called for each skipped job.
+	SkipUpToDate bool
+	OnSkip       func(index int, opts *Options)
+
+	OnStart    func(index int, opts *Options)
+	OnProgress func(index int, percent float64)
+	OnFinish   func(index int, opts *Options, err error)
+}
+
+type BatchFailure struct {
+	Input string
+	Err   error
+}
+
+// BatchError is returned when one or more jobs of a batch failed.
+type BatchError struct {
+	Total    int
+	Skipped  int
+	Failures []BatchFailure
+}
+
+func (e *BatchError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d of %d files failed", len(e.Failures), e.Total)
+	if e.Skipped > 0 {
+		fmt.Fprintf(&b, " (%d not processed)", e.Skipped)
+	}
+	b.WriteString(":")
+	for _, f := range e.Failures {
+		fmt.Fprintf(&b, "\n  %s: %v", f.Input, f.Err)
+	}
+	return b.String()
+}
+
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, f := range e.Failures {
+		errs[i] = f.Err
+	}
+	return errs
+}
+
+func ConvertBatch(b *Batch) error {
+	return defaultConverter.RunBatch(b)
+}
+
+// RunBatch converts every job in order and returns a *BatchError listing
+// the failed inputs, or nil if all of them succeeded.
+func (c *Converter) RunBatch(b *Batch) error {
+	batchErr := &BatchError{Total: len(b.Jobs)}
+
+	for i, opts := range b.Jobs {
+		err := c.Prepare(opts)
+		if err == nil && b.SkipUpToDate && UpToDate(opts) {
+			if b.OnSkip != nil {
+				b.OnSkip(i, opts)
+			}
+			continue
+		}
+		if err == nil {
+			if b.OnStart != nil {
+				b.OnStart(i, opts)
+			}
+			var onProgress ProgressFunc
+			if b.OnProgress != nil {
+				onProgress = func(percent float64) { b.OnProgress(i, percent) }
+			}
+			err = c.Run(opts, onProgress)
+		}
+
+		if b.OnFinish != nil {
+			b.OnFinish(i, opts, err)
+		}
+		if err != nil {
+			batchErr.Failures = append(batchErr.Failures, BatchFailure{Input: opts.Input, Err: err})
+			if b.FailFast {
+				batchErr.Skipped = len(b.Jobs) - i - 1
+				break
+			}
+		}
+	}
+
+	if len(batchErr.Failures) > 0 {
+		return batchErr
+	}
+	return nil
+}
+
+// UpToDate reports whether the resolved output of opts exists and is no
+// older than its input, like make decides a target needs no rebuild.
+// In-place conversions are never up to date.
+func UpToDate(opts *Options) bool {
+	if opts.InPlace {
+		return false
+	}
+	in, err := os.Stat(opts.Input)
+	if err != nil {
+		return false
+	}
+	out, err := os.Stat(opts.Output)
+	if err != nil {
+		return false
+	}
+	return !out.ModTime().Before(in.ModTime())
+}
@@ -0,0 +1,106 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+type BenchmarkCase struct {
+	Codec   string
+	Quality Quality
+}
+
+type BenchmarkResult struct {
+	BenchmarkCase
+	EncodeTime time.Duration
+	Size       int64
+	Scores     *QualityScores
+	Err        error
+}
+
+type BenchmarkOptions struct {
+	Input          string
+	SampleDuration time.Duration
+	Cases          []BenchmarkCase
+
+	// Metrics also scores each encode against the source (PSNR, plus VMAF
+	// when available). This is slower than the encodes themselves.
+	Metrics bool
+}
+
+func Benchmark(opts *BenchmarkOptions, onResult func(BenchmarkResult)) ([]BenchmarkResult, error) {
+	return defaultConverter.Benchmark(context.Background(), opts, onResult)
+}
+
+// Benchmark encodes the first SampleDuration of the input once per case and
+// measures each encode. A failing case is recorded in its result rather
+// than aborting the run.
+func (c *Converter) Benchmark(ctx context.Context, opts *BenchmarkOptions, onResult func(BenchmarkResult)) ([]BenchmarkResult, error) {
+	if _, err := os.Stat(opts.Input); os.IsNotExist(err) {
+		return nil, fmt.Errorf("input file does not exist: %s", opts.Input)
+	}
+	if opts.SampleDuration <= 0 {
+		return nil, fmt.Errorf("invalid sample duration: %s (must be positive)", opts.SampleDuration)
+	}
+	if len(opts.Cases) == 0 {
+		return nil, fmt.Errorf("no benchmark cases given")
+	}
+
+	temps := &cleanup{}
+	defer temps.removeAll()
+
+	var results []BenchmarkResult
+	for _, bc := range opts.Cases {
+		result := c.benchmarkCase(ctx, opts, bc, temps)
+		if ctx.Err() != nil {
+			return results, fmt.Errorf("benchmark interrupted: %w", ctx.Err())
+		}
+		results = append(results, result)
+		if onResult != nil {
+			onResult(result)
+		}
+	}
+	return results, nil
+}
+
+func (c *Converter) benchmarkCase(ctx context.Context, opts *BenchmarkOptions, bc BenchmarkCase, temps *cleanup) BenchmarkResult {
+	result := BenchmarkResult{BenchmarkCase: bc}
+
+	tmp, err := temps.createTemp("", "fk-converter-bench-*.mkv")
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	tmp.Close()
+
+	caseOpts := &Options{
+		Input:          opts.Input,
+		Output:         tmp.Name(),
+		Format:         "mkv",
+		Codec:          bc.Codec,
+		Quality:        bc.Quality,
+		SampleDuration: opts.SampleDuration,
+	}
+	if err := c.ValidateOptions(caseOpts); err != nil {
+		result.Err = err
+		return result
+	}
+
+	start := time.Now()
+	if err := c.runFFmpeg(ctx, buildFFmpegArgs(caseOpts, nil), progressTotal{}, nil); err != nil {
+		result.Err = fmt.Errorf("encode failed: %w", err)
+		return result
+	}
+	result.EncodeTime = time.Since(start)
+
+	if info, err := os.Stat(tmp.Name()); err == nil {
+		result.Size = info.Size()
+	}
+
+	if opts.Metrics {
+		result.Scores, result.Err = c.measureQuality(opts.Input, tmp.Name(), opts.SampleDuration, "")
+	}
+	return result
+}
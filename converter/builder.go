@@ -0,0 +1,127 @@
+package converter
+
+import "time"
+
+// OptionsBuilder builds Options step by step, checking each value as it is
+// set:
+//
+//	opts, err := converter.NewOptions("in.mov").
+//		WithCodec("h265").
+//		WithQuality(converter.QualityHigh).
+//		WithResolution("1080p").
+//		Build()
+//
+// The first invalid value is kept and returned by Build, so errors need
+// only be checked once. Options can still be filled in directly.
+type OptionsBuilder struct {
+	opts Options
+	err  error
+}
+
+func NewOptions(input string) *OptionsBuilder {
+	return &OptionsBuilder{opts: Options{Input: input}}
+}
+
+// set applies a change unless an earlier step failed, then keeps check's
+// error, if any.
+func (b *OptionsBuilder) set(apply func(*Options), check error) *OptionsBuilder {
+	if b.err != nil {
+		return b
+	}
+	if check != nil {
+		b.err = invalidOptions(check)
+		return b
+	}
+	apply(&b.opts)
+	return b
+}
+
+func (b *OptionsBuilder) WithOutput(path string) *OptionsBuilder {
+	return b.set(func(o *Options) { o.Output = path }, nil)
+}
+
+func (b *OptionsBuilder) WithFormat(format string) *OptionsBuilder {
+	return b.set(func(o *Options) { o.Format = format }, validateFormat(format))
+}
+
+func (b *OptionsBuilder) WithCodec(codec string) *OptionsBuilder {
+	return b.set(func(o *Options) { o.Codec = codec }, validateCodec(codec))
+}
+
+func (b *OptionsBuilder) WithQuality(quality Quality) *OptionsBuilder {
+	return b.set(func(o *Options) { o.Quality = quality }, validateQuality(quality))
+}
+
+func (b *OptionsBuilder) WithResolution(res string) *OptionsBuilder {
+	return b.set(func(o *Options) { o.Resolution = res }, validateResolution(res))
+}
+
+func (b *OptionsBuilder) WithPresetBundle(name string) *OptionsBuilder {
+	return b.set(func(o *Options) { o.PresetBundle = name }, validatePresetBundle(name))
+}
+
+func (b *OptionsBuilder) WithAudioBitrate(bitrate string) *OptionsBuilder {
+	return b.set(func(o *Options) { o.AudioBitrate = bitrate }, nil)
+}
+
+// WithStart skips the first start of the input.
+func (b *OptionsBuilder) WithStart(start time.Duration) *OptionsBuilder {
+	return b.set(func(o *Options) { o.Start = start }, nil)
+}
+
+// WithSample only converts the first d of the input, see SampleDuration.
+func (b *OptionsBuilder) WithSample(d time.Duration) *OptionsBuilder {
+	return b.set(func(o *Options) { o.SampleDuration = d }, nil)
+}
+
+func (b *OptionsBuilder) WithVideoFilter(filter string) *OptionsBuilder {
+	return b.set(func(o *Options) { o.CustomVideoFilter = filter }, nil)
+}
+
+func (b *OptionsBuilder) WithAudioFilter(filter string) *OptionsBuilder {
+	return b.set(func(o *Options) { o.CustomAudioFilter = filter }, nil)
+}
+
+func (b *OptionsBuilder) WithHWDecode(accel string) *OptionsBuilder {
+	return b.set(func(o *Options) { o.HWDecode = accel }, nil)
+}
+
+func (b *OptionsBuilder) WithTargetLUFS(lufs float64) *OptionsBuilder {
+	return b.set(func(o *Options) { o.TargetLUFS = lufs }, nil)
+}
+
+func (b *OptionsBuilder) WithSceneDetect(threshold float64) *OptionsBuilder {
+	return b.set(func(o *Options) { o.SceneDetect = threshold }, validateSceneThreshold(threshold))
+}
+
+// WithSubtitles adds a subtitle track; language may be empty.
+func (b *OptionsBuilder) WithSubtitles(path, language string) *OptionsBuilder {
+	return b.set(func(o *Options) {
+		o.Subtitles = append(o.Subtitles, SubtitleTrack{Path: path, Language: language})
+	}, nil)
+}
+
+// With sets fields that have no method of their own:
+//
+//	b.With(func(o *converter.Options) { o.Fragmented = true })
+func (b *OptionsBuilder) With(apply func(*Options)) *OptionsBuilder {
+	return b.set(apply, nil)
+}
+
+// Build returns the options, or the first invalid value set. The options
+// are also validated as a whole, like ValidateOptions, which catches
+// invalid combinations. The output path is resolved only to validate them:
+// the returned Options leave it to Run, which applies the converter's
+// Defaults first.
+func (b *OptionsBuilder) Build() (*Options, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	resolved := b.opts
+	ResolveOutput(&resolved)
+	if err := ValidateOptions(&resolved); err != nil {
+		return nil, err
+	}
+	opts := b.opts
+	return &opts, nil
+}
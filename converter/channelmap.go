@@ -0,0 +1,94 @@
+package converter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// channelLayouts are the layout names --channel-map converts between, with
+// their channel counts.
+var channelLayouts = map[string]int{
+	"mono":      1,
+	"stereo":    2,
+	"2.1":       3,
+	"3.0":       3,
+	"4.0":       4,
+	"quad":      4,
+	"5.0":       5,
+	"5.0(side)": 5,
+	"5.1":       6,
+	"5.1(side)": 6,
+	"6.1":       7,
+	"7.1":       8,
+}
+
+// swapLayouts names the layout of each channel count swap-lr handles. All
+// of them start with the front left/right pair.
+var swapLayouts = map[int]string{
+	2: "stereo",
+	6: "5.1",
+	8: "7.1",
+}
+
+func validateChannelMap(channelMap string) error {
+	switch {
+	case strings.HasPrefix(channelMap, "pan="), channelMap == "swap-lr":
+		return nil
+	case strings.Contains(channelMap, "->"):
+		from, to, _ := strings.Cut(channelMap, "->")
+		for _, layout := range []string{from, to} {
+			if _, ok := channelLayouts[layout]; !ok {
+				return fmt.Errorf("unsupported channel layout: %s (supported: mono, stereo, 2.1, 3.0, 4.0, quad, 5.0, 5.0(side), 5.1, 5.1(side), 6.1, 7.1)", layout)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported channel map: %s (examples: 5.1->7.1, swap-lr, pan=stereo|FL=c1|FR=c0)", channelMap)
+}
+
+// checkChannelMapSource rejects a channel map that doesn't fit the input's
+// audio, which ffmpeg would only report as a failed filter graph.
+func checkChannelMapSource(opts *Options, src *ProbeInfo) error {
+	channels := audioChannels(src)
+	if opts.ChannelMap == "" || channels == 0 {
+		return nil
+	}
+	if opts.ChannelMap == "swap-lr" {
+		if _, ok := swapLayouts[channels]; !ok {
+			return fmt.Errorf("--channel-map swap-lr: unsupported %d-channel audio (supported: stereo, 5.1, 7.1)", channels)
+		}
+		return nil
+	}
+	if from, _, ok := strings.Cut(opts.ChannelMap, "->"); ok && channelLayouts[from] != channels {
+		return fmt.Errorf("--channel-map %s: the audio has %d channel(s), %s has %d", opts.ChannelMap, channels, from, channelLayouts[from])
+	}
+	return nil
+}
+
+// channelMapFilter builds the filter for ChannelMap. "from->to" first
+// labels the channels as from, in their current order, which fixes
+// mislabeled files, then lets ffmpeg remix them to to if it differs.
+// swap-lr exchanges the front left and right channels. pan= expressions
+// are used as they are.
+func channelMapFilter(opts *Options, src *ProbeInfo) string {
+	switch {
+	case opts.ChannelMap == "":
+		return ""
+	case strings.HasPrefix(opts.ChannelMap, "pan="):
+		return opts.ChannelMap
+	case opts.ChannelMap == "swap-lr":
+		channels := max(audioChannels(src), 2)
+		parts := []string{"pan=" + swapLayouts[channels], "c0=c1", "c1=c0"}
+		for i := 2; i < channels; i++ {
+			parts = append(parts, "c"+strconv.Itoa(i)+"=c"+strconv.Itoa(i))
+		}
+		return strings.Join(parts, "|")
+	}
+	from, to, _ := strings.Cut(opts.ChannelMap, "->")
+	filter := "channelmap=channel_layout=" + from
+	if to != from {
+		filter += ",aformat=channel_layouts=" + to
+	}
+	return filter
+}
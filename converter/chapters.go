@@ -0,0 +1,198 @@
+package converter
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const ffmetadataHeader = ";FFMETADATA1"
+
+// chapterFormats are the containers that can hold chapters.
+var chapterFormats = map[string]bool{
+	"mp4":  true,
+	"mkv":  true,
+	"mov":  true,
+	"webm": true,
+}
+
+type chapter struct {
+	Start time.Duration
+	Title string
+}
+
+// parseChapters reads a chapters file with one "timestamp title" line per
+// chapter, e.g. "1:02:03.5 Q&A". Blank lines and lines starting with # are
+// skipped. Start times must increase.
+func parseChapters(path string) ([]chapter, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chapters file: %w", err)
+	}
+	defer f.Close()
+
+	var chapters []chapter
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		stamp, title, _ := strings.Cut(line, " ")
+		start, err := parseTimestamp(stamp)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid timestamp %q (examples: 0:00, 12:30, 1:02:03.5)", path, n, stamp)
+		}
+		if len(chapters) > 0 && start <= chapters[len(chapters)-1].Start {
+			return nil, fmt.Errorf("%s:%d: chapter at %s does not come after the previous one", path, n, stamp)
+		}
+		chapters = append(chapters, chapter{Start: start, Title: strings.TrimSpace(title)})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read chapters file: %w", err)
+	}
+	if len(chapters) == 0 {
+		return nil, fmt.Errorf("no chapters found in %s", path)
+	}
+	return chapters, nil
+}
+
+// parseTimestamp parses [[hh:]mm:]ss[.fff].
+func parseTimestamp(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("too many fields")
+	}
+	var seconds float64
+	for _, p := range parts {
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("invalid field %q", p)
+		}
+		seconds = seconds*60 + v
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+func isFFMetadata(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	header := make([]byte, len(ffmetadataHeader))
+	n, _ := f.Read(header)
+	return string(header[:n]) == ffmetadataHeader
+}
+
+func validateChapters(opts *Options) error {
+	if !chapterFormats[opts.Format] {
+		return fmt.Errorf("--chapters is not supported for %s output (supported: mp4, mkv, mov, webm)", opts.Format)
+	}
+	return validateChaptersFile(opts.Chapters)
+}
+
+func validateChaptersFile(path string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return fmt.Errorf("chapters file does not exist: %s", path)
+	}
+	if isFFMetadata(path) {
+		return nil
+	}
+	_, err := parseChapters(path)
+	return err
+}
+
+// writeChapterMetadata converts a chapters file to ffmetadata in a
+// temporary file, ending each chapter where the next starts and the last at
+// the end of the output. ffmetadata files are used as they are.
+func writeChapterMetadata(path string, duration time.Duration, temps *cleanup) (string, error) {
+	if isFFMetadata(path) {
+		return path, nil
+	}
+	chapters, err := parseChapters(path)
+	if err != nil {
+		return "", err
+	}
+
+	if duration > 0 {
+		if last := chapters[len(chapters)-1]; last.Start >= duration {
+			return "", fmt.Errorf("chapter %q starts at %s, after the end of the video (%s)", last.Title, last.Start, duration.Round(time.Millisecond))
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(ffmetadataHeader + "\n")
+	for i, ch := range chapters {
+		end := duration
+		if i+1 < len(chapters) {
+			end = chapters[i+1].Start
+		}
+		if end <= ch.Start {
+			// Unknown duration: give the last chapter a nominal length.
+			end = ch.Start + time.Second
+		}
+		fmt.Fprintf(&b, "[CHAPTER]\nTIMEBASE=1/1000\nSTART=%d\nEND=%d\ntitle=%s\n",
+			ch.Start.Milliseconds(), end.Milliseconds(), escapeFFMetadata(ch.Title))
+	}
+
+	return writeFFMetadata(b.String(), temps)
+}
+
+// trimChapterMetadata writes the source's chapters that overlap the part
+// of it kept in a trimmed output, from start for limit (to the end if
+// zero), as ffmetadata. They are cut to that range and rebased to start,
+// so chapter navigation points at the right times; chapters outside it are
+// dropped. ffmpeg rebases them itself when seeking on the output, so with
+// outputSeek they keep their source times.
+func trimChapterMetadata(chapters []ChapterInfo, start, limit time.Duration, outputSeek bool, temps *cleanup) (string, error) {
+	end := time.Duration(math.MaxInt64)
+	if limit > 0 {
+		end = start + limit
+	}
+	offset := start
+	if outputSeek {
+		offset = 0
+	}
+
+	var b strings.Builder
+	b.WriteString(ffmetadataHeader + "\n")
+	for _, ch := range chapters {
+		if ch.End <= start || ch.Start >= end {
+			continue
+		}
+		fmt.Fprintf(&b, "[CHAPTER]\nTIMEBASE=1/1000\nSTART=%d\nEND=%d\ntitle=%s\n",
+			(max(ch.Start, start) - offset).Milliseconds(), (min(ch.End, end) - offset).Milliseconds(), escapeFFMetadata(ch.Title))
+	}
+	return writeFFMetadata(b.String(), temps)
+}
+
+func writeFFMetadata(metadata string, temps *cleanup) (string, error) {
+	f, err := temps.createTemp("", "fk-converter-chapters-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("failed to write chapter metadata: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(metadata); err != nil {
+		return "", fmt.Errorf("failed to write chapter metadata: %w", err)
+	}
+	return f.Name(), nil
+}
+
+// escapeFFMetadata escapes the characters ffmetadata treats specially.
+func escapeFFMetadata(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`).Replace(s)
+}
+
+// chapterInputIndex is the ffmpeg input index of the chapter metadata,
+// after the input and the optional extra audio input.
+func chapterInputIndex(opts *Options) int {
+	if opts.Audio != "" || opts.ReplaceAudio != "" || opts.BackgroundAudio != "" {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,37 @@
+package converter
+
+import (
+	"os"
+	"sync"
+)
+
+// cleanup tracks the temporary files a conversion creates so they are
+// removed when it finishes, fails or is interrupted.
+type cleanup struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+func (c *cleanup) createTemp(dir, pattern string) (*os.File, error) {
+	f, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+	c.track(f.Name())
+	return f, nil
+}
+
+func (c *cleanup) track(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paths = append(c.paths, path)
+}
+
+func (c *cleanup) removeAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, p := range c.paths {
+		os.Remove(p)
+	}
+	c.paths = nil
+}
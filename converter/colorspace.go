@@ -0,0 +1,91 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// colorSpace describes a target color space: the colorspace filter's name
+// for it and the tags written to the output.
+type colorSpace struct {
+	filter    string
+	matrix    string
+	primaries string
+	transfer  string
+}
+
+var colorSpaces = map[string]colorSpace{
+	"bt601":  {"smpte170m", "smpte170m", "smpte170m", "smpte170m"},
+	"bt709":  {"bt709", "bt709", "bt709", "bt709"},
+	"bt2020": {"bt2020", "bt2020nc", "bt2020", "bt2020-10"},
+}
+
+// sourceColorSpaces maps ffprobe's color_space values to our names.
+var sourceColorSpaces = map[string]string{
+	"smpte170m": "bt601",
+	"bt470bg":   "bt601",
+	"bt709":     "bt709",
+	"bt2020nc":  "bt2020",
+	"bt2020c":   "bt2020",
+}
+
+// hdrTransfers are transfer characteristics the colorspace filter can't
+// convert, since that takes tone mapping.
+var hdrTransfers = map[string]bool{
+	"smpte2084":    true,
+	"arib-std-b67": true,
+}
+
+func validateColorSpace(name string) error {
+	if _, ok := colorSpaces[name]; !ok {
+		return fmt.Errorf("unsupported color space: %s (supported: bt601, bt709, bt2020)", name)
+	}
+	return nil
+}
+
+// sourceColorSpace returns the input's color space, from its tags or, for
+// untagged video, guessed from the resolution the way players do: SD is
+// BT.601, anything larger BT.709.
+func sourceColorSpace(src *ProbeInfo) string {
+	v := src.VideoStream()
+	if v == nil {
+		return ""
+	}
+	if name, ok := sourceColorSpaces[v.ColorSpace]; ok {
+		return name
+	}
+	if v.Height > 0 && v.Height <= 576 {
+		return "bt601"
+	}
+	return "bt709"
+}
+
+// colorSpaceFilter converts the pixels from the source's color space to
+// opts.ColorSpace. It returns "" when they already match, in which case
+// only the output tags change.
+func colorSpaceFilter(opts *Options, src *ProbeInfo) string {
+	from := sourceColorSpace(src)
+	if opts.ColorSpace == "" || from == "" || from == opts.ColorSpace {
+		return ""
+	}
+	return fmt.Sprintf("colorspace=all=%s:iall=%s", colorSpaces[opts.ColorSpace].filter, colorSpaces[from].filter)
+}
+
+// colorTagArgs tags the output with the target color space so players
+// decode it correctly.
+func colorTagArgs(opts *Options) []string {
+	cs, ok := colorSpaces[opts.ColorSpace]
+	if !ok {
+		return nil
+	}
+	return []string{
+		"-colorspace", cs.matrix,
+		"-color_primaries", cs.primaries,
+		"-color_trc", cs.transfer,
+	}
+}
+
+func isHDR(src *ProbeInfo) bool {
+	v := src.VideoStream()
+	return v != nil && hdrTransfers[strings.ToLower(v.ColorTransfer)]
+}
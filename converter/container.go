@@ -0,0 +1,57 @@
+package converter
+
+import (
+	"slices"
+	"strings"
+)
+
+// containerExtensions maps ffprobe's format_name to the extensions files
+// of that container use, the usual one first. ffprobe can't tell mp4 from
+// mov or mkv from webm, so those share an entry.
+var containerExtensions = map[string][]string{
+	"mov,mp4,m4a,3gp,3g2,mj2": {"mp4", "mov", "m4a", "m4v", "m4b", "3gp", "3g2", "mj2"},
+	"matroska,webm":           {"mkv", "webm", "mka"},
+	"avi":                     {"avi"},
+	"mpegts":                  {"ts", "m2ts", "mts"},
+	"mpeg":                    {"mpg", "mpeg", "vob"},
+	"flv":                     {"flv"},
+	"asf":                     {"wmv", "asf", "wma"},
+	"ogg":                     {"ogg", "ogv", "oga", "opus"},
+	"gif":                     {"gif"},
+	"mp3":                     {"mp3"},
+	"wav":                     {"wav"},
+	"flac":                    {"flac"},
+}
+
+// Container returns the extension that matches what the file really is,
+// judged by its content rather than its name, e.g. "mkv" for a Matroska
+// file saved as .mp4. It is empty for containers it doesn't know.
+func (p *ProbeInfo) Container() string {
+	if p == nil {
+		return ""
+	}
+	if exts := containerExtensions[p.FormatName]; len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
+
+// MatchesExtension reports whether path's extension fits the probed
+// container. Unknown containers always match.
+func (p *ProbeInfo) MatchesExtension(path string) bool {
+	if p == nil {
+		return true
+	}
+	exts, ok := containerExtensions[p.FormatName]
+	return !ok || slices.Contains(exts, strings.ToLower(getExtension(path)))
+}
+
+// inputExtension returns the extension the input's content calls for: its
+// own, unless the probe found a different container that fk-converter can
+// write (an mkv named .mp4 gives "mkv"). Without a probe it trusts the name.
+func inputExtension(path string, src *ProbeInfo) string {
+	if container := src.Container(); !src.MatchesExtension(path) && validateFormat(container) == nil {
+		return container
+	}
+	return getExtension(path)
+}
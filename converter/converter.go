@@ -1,14 +1,20 @@
 package converter
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"reflect"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 )
 
@@ -28,6 +34,15 @@ var crfMap = map[Quality]int{
 	QualityLossless: 0,
 }
 
+// audioBitrateMap is the audio bitrate of each quality preset. Lossless
+// only uses it for containers without a lossless audio codec.
+var audioBitrateMap = map[Quality]string{
+	QualityLow:      "96k",
+	QualityMedium:   "128k",
+	QualityHigh:     "192k",
+	QualityLossless: "192k",
+}
+
 var supportedFormats = map[string]bool{
 	"mp4":  true,
 	"mkv":  true,
@@ -36,93 +51,1084 @@ var supportedFormats = map[string]bool{
 	"mov":  true,
 }
 
-var codecMap = map[string]string{
-	"h264": "libx264",
-	"h265": "libx265",
-	"vp9":  "libvpx-vp9",
+var fastStartFormats = map[string]bool{
+	"mp4": true,
+	"mov": true,
+	"m4v": true,
+}
+
+var codecMap = map[string]string{
+	"h264": "libx264",
+	"h265": "libx265",
+	"vp9":  "libvpx-vp9",
+}
+
+var codecProfiles = map[string][]string{
+	"libx264": {"baseline", "main", "high"},
+	"libx265": {"main", "main10"},
+}
+
+var codecTunes = map[string][]string{
+	"libx264": {"film", "animation", "grain", "stillimage", "fastdecode", "zerolatency", "psnr", "ssim"},
+	"libx265": {"animation", "grain", "fastdecode", "zerolatency", "psnr", "ssim"},
+}
+
+var levelRegex = regexp.MustCompile(`^\d(\.\d)?$`)
+
+var scaleAlgorithms = map[string]bool{
+	"bilinear": true,
+	"bicubic":  true,
+	"lanczos":  true,
+	"spline":   true,
+	"neighbor": true,
+	"area":     true,
+}
+
+var aspectRatioRegex = regexp.MustCompile(`^[1-9]\d*(\.\d+)?(:[1-9]\d*(\.\d+)?)?$`)
+
+type audioFormat struct {
+	codec   string
+	bitrate string
+}
+
+var audioFormats = map[string]audioFormat{
+	"mp3":  {"libmp3lame", "192k"},
+	"m4a":  {"aac", "192k"},
+	"aac":  {"aac", "192k"},
+	"opus": {"libopus", "128k"},
+	"ogg":  {"libvorbis", "192k"},
+	"flac": {"flac", ""},
+	"wav":  {"pcm_s16le", ""},
+}
+
+type Options struct {
+	Input      string
+	Output     string
+	Format     string
+	Quality    Quality
+	Resolution string
+	Codec      string
+	Loop       int
+
+	// MaxResolution caps the output resolution without upscaling: each
+	// input larger than it is scaled down, smaller ones keep their size.
+	// A preset like 1080p caps the short side; WxH is a box to fit in.
+	MaxResolution string
+
+	ExtractAudio string
+	AudioTrack   int
+
+	Profile string
+	Level   string
+
+	Deinterlace     bool
+	DeinterlaceMode string
+	DetectInterlace bool
+
+	// Interlace produces interlaced output with this field order, "tff"
+	// (top field first) or "bff", for broadcast delivery. h264 only.
+	Interlace string
+
+	HWDecode string
+
+	CustomVideoFilter string
+	CustomAudioFilter string
+
+	SampleDuration time.Duration
+
+	RateControl RateControl
+
+	NameTemplate string
+
+	KeyframeInterval int
+	KeyframeEvery    time.Duration
+
+	// SceneDetect finds the scene changes whose score exceeds this
+	// threshold (0-1, see DefaultSceneThreshold) in a first pass and forces
+	// a keyframe on each, for scene-aligned GOPs and clean cuts. Zero is
+	// off.
+	SceneDetect float64
+
+	// sceneCuts are the scene changes found while converting with
+	// SceneDetect.
+	sceneCuts []time.Duration
+
+	// NoFastStart leaves the moov atom at the end of MP4/MOV outputs. By
+	// default it is moved to the front so playback can start while
+	// downloading.
+	NoFastStart bool
+
+	// Fragmented writes a fragmented MP4/MOV, a sequence of self-contained
+	// fragments starting at each keyframe, for DASH and other streaming
+	// servers. The metadata is at the front, so FastStart isn't needed.
+	Fragmented bool
+
+	// VideoBitrate sets an explicit target bitrate such as "3M", overriding
+	// the quality preset's rate control.
+	VideoBitrate string
+
+	// InPlace replaces the input with the converted file. The output is
+	// written to a temporary file next to it and renamed over the input
+	// only once ffmpeg succeeds.
+	InPlace bool
+
+	// OutputDir places the output in this directory, keeping the file
+	// name it would otherwise get.
+	OutputDir string
+
+	// Renditions, if set, encodes the input into one file per rendition
+	// from a single decode, as RunLadder does, instead of into Output.
+	Renditions []Rendition
+
+	SampleRate int
+
+	// CoverArt and DefaultAudioTrack only apply to mkv output.
+	CoverArt          string
+	DefaultAudioTrack *int
+
+	// ReplaceAudio swaps the soundtrack for this audio file. The output
+	// ends with whichever of the video and the new audio is shorter.
+	ReplaceAudio string
+
+	// ConstantFrameRate re-times variable frame rate sources (typical of
+	// phone screen recordings) to a constant rate to keep A/V in sync.
+	ConstantFrameRate bool
+
+	// PresetBundle names a task-oriented set of options (youtube, archive,
+	// web, discord). Fields set explicitly override the bundle's values.
+	PresetBundle string
+
+	// Threads limits the encoder to this many threads; 0 lets ffmpeg pick.
+	Threads int
+
+	// SkipProbe skips checking that the input holds media streams before
+	// converting. Features that depend on the probe (progress, VFR
+	// detection, ...) then fall back to their defaults.
+	SkipProbe bool
+
+	// Verify probes the output after converting and fails if it is
+	// unreadable or noticeably shorter than the source. VerifyFull also
+	// decodes the whole output, which takes about as long as playing it
+	// back at decoding speed. VerifyFull implies Verify.
+	Verify     bool
+	VerifyFull bool
+
+	// AudioDelay shifts the audio relative to the video: positive values
+	// make it play later, negative values earlier.
+	AudioDelay time.Duration
+
+	// X264Params and X265Params are passed verbatim as -x264-params and
+	// -x265-params (e.g. "aq-mode=3:psy-rd=2.0") when that encoder is used,
+	// and ignored otherwise.
+	X264Params string
+	X265Params string
+
+	// Tune optimizes libx264/libx265 for a kind of content, e.g. "film",
+	// "animation" or "grain".
+	Tune string
+
+	// MapAll keeps every stream of the input (extra audio, subtitles,
+	// attachments, ...) that the output container can hold, instead of
+	// one video and one audio stream. Streams are copied unless an
+	// encoding option other than the default quality is set.
+	MapAll bool
+
+	// AspectRatio overrides the display aspect ratio (e.g. "16:9" or
+	// "2.35"), fixing sources with wrong aspect metadata that play back
+	// stretched. The pixels are not rescaled.
+	AspectRatio string
+
+	// ReadRate caps how fast the input is read, as a multiple of realtime
+	// (1 reads at playback speed), so conversions off shared network
+	// storage don't saturate it. 0 reads as fast as possible.
+	ReadRate float64
+
+	// ColorSpace converts the video to bt601, bt709 or bt2020 and tags the
+	// output accordingly. The source space comes from its tags, or is
+	// guessed from the resolution when untagged.
+	ColorSpace string
+
+	// BackgroundAudio mixes this audio file, looped as needed, under the
+	// input's soundtrack at BackgroundVolume (default 0.25). Silent inputs
+	// get the music alone.
+	BackgroundAudio  string
+	BackgroundVolume float64
+
+	// ScaleAlgorithm picks the scaler used with Resolution (bilinear,
+	// bicubic, lanczos, spline, neighbor, area). Lanczos gives noticeably
+	// sharper downscales.
+	ScaleAlgorithm string
+
+	// DropDuplicates drops frames nearly identical to the previous one
+	// (mpdecimate), which shrinks screen recordings and slideshows with
+	// long static stretches. Timestamps are kept, so audio stays in sync,
+	// but the output has a variable frame rate.
+	DropDuplicates bool
+
+	// Deterministic makes repeated conversions of the same input produce
+	// bit-identical files: metadata and timestamps are stripped and
+	// encoders with nondeterministic threading run single-threaded.
+	Deterministic bool
+
+	// Audio turns an image Input into a video of the audio's length, e.g.
+	// to upload a song to a video platform.
+	Audio string
+
+	// AudioLanguages and SubtitleLanguages tag the output's audio and
+	// subtitle streams, in order, with ISO 639-2 codes (eng, spa, ...) so
+	// players and media servers can list them by language.
+	AudioLanguages    []string
+	SubtitleLanguages []string
+
+	// Subtitles adds external subtitle files as tracks (mkv and mp4 only),
+	// after any subtitles kept from the input.
+	Subtitles []SubtitleTrack
+
+	// Chapters adds chapter markers from a file with one "timestamp title"
+	// line per chapter (e.g. "12:30 Results"), or from an ffmetadata file.
+	Chapters string
+
+	// Start skips the beginning of the input. By default ffmpeg seeks the
+	// input, which is fast but snaps to a keyframe when streams are copied.
+	// AccurateSeek decodes from the beginning and drops everything before
+	// Start instead: slower, but frame-exact.
+	Start        time.Duration
+	AccurateSeek bool
+
+	// NoAudioCopy always re-encodes the audio. By default, audio already
+	// in a codec the output container holds is copied, which is faster and
+	// avoids a lossy generation.
+	NoAudioCopy bool
+
+	// RotateAuto applies the source's rotation metadata (common in phone
+	// videos) to the pixels and clears it, so the output plays upright in
+	// players that ignore the metadata.
+	RotateAuto bool
+
+	// MaxDuration cuts the output at this length when the input would run
+	// longer, e.g. to meet an upload limit. Shorter inputs are unaffected.
+	MaxDuration time.Duration
+
+	// ForceReencode always encodes the video, even when the conversion is
+	// only a container change that could copy the streams.
+	ForceReencode bool
+
+	// Fallback switches to a compatible codec (h265 or vp9 to h264) with a
+	// warning when ffmpeg lacks the requested encoder, instead of failing.
+	Fallback bool
+
+	// FileMode, if set, is applied to the output (and the extracted audio)
+	// after converting. Otherwise ffmpeg creates them according to the
+	// process umask.
+	FileMode os.FileMode
+
+	// MaxSize is a size budget for the output in bytes. When the encode
+	// exceeds it, it is redone at a higher CRF, estimated from how far over
+	// it was, up to MinQualityCRF, the lowest quality allowed. The first
+	// attempt that fits, or the one at MinQualityCRF, is kept. OnFitAttempt
+	// is called after each attempt.
+	MaxSize       int64
+	MinQualityCRF int
+	OnFitAttempt  func(attempt, crf int, size int64)
+
+	// crf overrides the quality preset's CRF during an attempt to fit
+	// MaxSize.
+	crf int
+
+	// Nice runs ffmpeg at this CPU scheduling niceness, from 0 (normal) to
+	// 19 (lowest priority), so background conversions leave the machine
+	// responsive. Unix only.
+	Nice int
+
+	// PreserveTimestamps sets the output's modification time to the
+	// input's (TimestampFile) or to when the media was recorded, from its
+	// creation_time tag (TimestampMedia), so date-sorted listings stay in
+	// order after converting. Empty leaves the time of conversion.
+	PreserveTimestamps TimestampSource
+
+	// AudioBitrate overrides the quality preset's audio bitrate, e.g. "160k".
+	AudioBitrate string
+
+	// DownmixStereo mixes surround audio (5.1, 7.1) down to stereo with
+	// every channel audible, for devices that would otherwise play only
+	// the front or center channels.
+	DownmixStereo bool
+
+	// ExtraArgs are passed to ffmpeg after the generated options, just
+	// before the output path, with {input} and {output} replaced by the
+	// paths. They are not validated and can contradict the generated
+	// options; for the same option, ffmpeg uses the last one given.
+	ExtraArgs []string
+
+	// AutoQuality adjusts the quality preset's CRF to the output
+	// resolution, for consistent perceived quality across mixed inputs.
+	AutoQuality bool
+
+	// SmoothFPS converts to this frame rate with motion interpolation
+	// (minterpolate), generating in-between frames instead of repeating or
+	// dropping them. It is very slow.
+	SmoothFPS float64
+
+	// Mute silences the audio in each "start-end" time range of the input,
+	// e.g. "1:05-1:12", leaving the video untouched. Overlapping ranges are
+	// merged.
+	Mute []string
+
+	// ChannelMap relabels or remixes the audio channels: "5.1->7.1" treats
+	// the channels as 5.1 in their current order and remixes them to 7.1,
+	// "swap-lr" exchanges left and right, and "pan=..." is used as ffmpeg's
+	// pan filter.
+	ChannelMap string
+
+	// TargetLUFS normalizes the audio to this integrated loudness with
+	// ffmpeg's loudnorm (EBU R128), e.g. -16 for podcasts or -23 for
+	// broadcast. TargetTruePeak (dBTP, default -1.5) and TargetLRA (LU,
+	// default 11) set its other targets. LoudnessTwoPass measures the
+	// audio first, for exact results at the cost of an extra decode.
+	TargetLUFS      float64
+	TargetTruePeak  float64
+	TargetLRA       float64
+	LoudnessTwoPass bool
+
+	// loudness is the first pass measurement while converting with
+	// LoudnessTwoPass.
+	loudness *loudnessMeasurement
+
+	// OnProgressDetail, if set, receives the full progress state (frame,
+	// speed, smoothed ETA, ...) alongside the percentage callback.
+	OnProgressDetail func(Progress)
+}
+
+type ProgressFunc func(percent float64)
+
+// StatsFunc receives encoder statistics: frames written so far, encoding
+// frames per second, output bitrate in kbit/s and speed as a multiple of
+// realtime, all as reported by ffmpeg without smoothing.
+type StatsFunc func(frame int64, fps, bitrate, speed float64)
+
+// Converter runs conversions with a configurable ffmpeg installation. The
+// zero value uses ffmpeg and ffprobe from PATH and discards ffmpeg's log.
+type Converter struct {
+	FFmpegPath  string
+	FFprobePath string
+
+	// Logger receives ffmpeg's log output, excluding progress lines.
+	Logger io.Writer
+
+	// Defaults fills in any option left unset by the caller.
+	Defaults *Options
+
+	// Warn receives advisory messages about questionable settings. When
+	// nil they are printed to stderr.
+	Warn func(msg string)
+
+	// OnStats, if set, receives the raw encoder statistics of every ffmpeg
+	// progress block (about twice a second) of every encode this converter
+	// runs, including ones whose length is unknown.
+	OnStats StatsFunc
+
+	version  versionCache
+	encoders encoderCache
+}
+
+var defaultConverter = &Converter{}
+
+func CheckFFmpeg() error {
+	return defaultConverter.CheckFFmpeg()
+}
+
+func ValidateOptions(opts *Options) error {
+	return defaultConverter.ValidateOptions(opts)
+}
+
+func Convert(opts *Options, onProgress ProgressFunc) error {
+	return defaultConverter.Run(opts, onProgress)
+}
+
+func ConvertContext(ctx context.Context, opts *Options, onProgress ProgressFunc) error {
+	return defaultConverter.RunContext(ctx, opts, onProgress)
+}
+
+func (c *Converter) ffmpeg() string {
+	if c.FFmpegPath != "" {
+		return c.FFmpegPath
+	}
+	return "ffmpeg"
+}
+
+func (c *Converter) ffprobe() string {
+	if c.FFprobePath != "" {
+		return c.FFprobePath
+	}
+	return "ffprobe"
+}
+
+// CheckFFmpeg checks that ffmpeg is installed and detects its version for
+// FFmpegVersion and RequireFFmpegVersion.
+func (c *Converter) CheckFFmpeg() error {
+	_, err := exec.LookPath(c.ffmpeg())
+	if err != nil {
+		return fmt.Errorf("%w in PATH. Install it:\n  macOS:  brew install ffmpeg\n  Ubuntu: sudo apt install ffmpeg\n  Windows: https://ffmpeg.org/download.html", ErrFFmpegNotFound)
+	}
+	c.FFmpegVersion()
+	return nil
+}
+
+// Prepare applies the converter's defaults, resolves the output path and
+// validates the result. Run calls it too, so callers only need it when they
+// want the resolved options before converting.
+func (c *Converter) Prepare(opts *Options) error {
+	if c.Defaults != nil {
+		applyDefaults(opts, c.Defaults)
+	}
+	c.ResolveOutput(opts)
+	return c.ValidateOptions(opts)
+}
+
+// ValidateOptions checks opts before converting. Every error it returns
+// matches ErrInvalidOptions.
+func (c *Converter) ValidateOptions(opts *Options) error {
+	return invalidOptions(c.validateOptions(opts))
+}
+
+func (c *Converter) validateOptions(opts *Options) error {
+	if _, err := os.Stat(opts.Input); os.IsNotExist(err) {
+		return fmt.Errorf("input file does not exist: %s", opts.Input)
+	}
+
+	if opts.PresetBundle != "" {
+		if err := validatePresetBundle(opts.PresetBundle); err != nil {
+			return err
+		}
+	}
+
+	if err := validateFormat(opts.Format); err != nil {
+		return err
+	}
+
+	if err := validateCodec(opts.Codec); err != nil {
+		return err
+	}
+
+	if err := validateQuality(opts.Quality); err != nil {
+		return err
+	}
+
+	if opts.Loop < 0 {
+		return fmt.Errorf("invalid loop count: %d (must be 0 or greater)", opts.Loop)
+	}
+
+	if opts.RateControl != "" && opts.RateControl != RateControlCRF && opts.RateControl != RateControlBitrate {
+		return fmt.Errorf("unsupported rate control: %s (supported: crf, bitrate)", opts.RateControl)
+	}
+
+	if opts.NameTemplate != "" {
+		if err := validateNameTemplate(opts.NameTemplate); err != nil {
+			return err
+		}
+		if base := filepath.Base(opts.Output); base == "." || strings.HasPrefix(base, ".") {
+			return fmt.Errorf("name template %q produces an empty file name", opts.NameTemplate)
+		}
+	}
+
+	if opts.VideoBitrate != "" && !bitrateRegex.MatchString(opts.VideoBitrate) {
+		return fmt.Errorf("invalid video bitrate: %s (examples: 6M, 1.5M, 800k)", opts.VideoBitrate)
+	}
+
+	if opts.SampleRate < 0 {
+		return fmt.Errorf("invalid sample rate: %d (must be positive)", opts.SampleRate)
+	}
+
+	if opts.ReadRate < 0 {
+		return fmt.Errorf("invalid read rate: %g (must be 0 or greater)", opts.ReadRate)
+	}
+	if opts.ReadRate > 0 {
+		if err := c.RequireFFmpegVersion("5.0"); err != nil {
+			return fmt.Errorf("--readrate: %w", err)
+		}
+	}
+
+	if opts.Threads < 0 {
+		return fmt.Errorf("invalid thread count: %d (must be 0 or greater)", opts.Threads)
+	}
+
+	if opts.KeyframeInterval < 0 {
+		return fmt.Errorf("invalid GOP size: %d (must be positive)", opts.KeyframeInterval)
+	}
+
+	if opts.KeyframeEvery < 0 {
+		return fmt.Errorf("invalid keyframe interval: %s (must be positive)", opts.KeyframeEvery)
+	}
+
+	if opts.SceneDetect != 0 {
+		if err := validateSceneThreshold(opts.SceneDetect); err != nil {
+			return err
+		}
+		if opts.KeyframeEvery > 0 {
+			return fmt.Errorf("--scene-detect cannot be combined with --keyframe-every")
+		}
+	}
+
+	if opts.Start < 0 {
+		return fmt.Errorf("invalid start time: %s (must be positive)", opts.Start)
+	}
+
+	if opts.SampleDuration < 0 {
+		return fmt.Errorf("invalid sample duration: %s (must be positive)", opts.SampleDuration)
+	}
+
+	if opts.AudioBitrate != "" && !bitrateRegex.MatchString(opts.AudioBitrate) {
+		return fmt.Errorf("invalid audio bitrate: %s (examples: 96k, 192k)", opts.AudioBitrate)
+	}
+
+	if err := validateMaxSize(opts); err != nil {
+		return err
+	}
+
+	if err := validateNice(opts.Nice); err != nil {
+		return err
+	}
+
+	if opts.PreserveTimestamps != "" && opts.PreserveTimestamps != TimestampFile && opts.PreserveTimestamps != TimestampMedia {
+		return fmt.Errorf("unsupported timestamp source: %s (supported: file, media)", opts.PreserveTimestamps)
+	}
+
+	if opts.FileMode&^os.ModePerm != 0 {
+		return fmt.Errorf("invalid file mode: %s (only permission bits, e.g. 0644)", opts.FileMode)
+	}
+
+	if opts.MaxDuration < 0 {
+		return fmt.Errorf("invalid max duration: %s (must be positive)", opts.MaxDuration)
+	}
+
+	if _, err := muteRanges(opts); err != nil {
+		return err
+	}
+
+	if opts.Fragmented && !fastStartFormats[opts.Format] {
+		return fmt.Errorf("--fragmented is not supported for %s output (supported: mp4, mov)", opts.Format)
+	}
+
+	if opts.ChannelMap != "" {
+		if err := validateChannelMap(opts.ChannelMap); err != nil {
+			return err
+		}
+	}
+
+	if err := validateLoudness(opts); err != nil {
+		return err
+	}
+
+	if opts.ExtractAudio != "" {
+		ext := getExtension(opts.ExtractAudio)
+		if _, ok := audioFormats[ext]; !ok {
+			return fmt.Errorf("unsupported audio format for --extract-audio: %q (supported: mp3, m4a, aac, opus, ogg, flac, wav)", ext)
+		}
+		if opts.ExtractAudio == opts.Output {
+			return fmt.Errorf("--extract-audio path must differ from the output file")
+		}
+	}
+
+	if opts.AudioTrack < 0 {
+		return fmt.Errorf("invalid audio track: %d (must be 0 or greater)", opts.AudioTrack)
+	}
+
+	if err := validateResolution(opts.Resolution); err != nil {
+		return err
+	}
+
+	if opts.MaxResolution != "" {
+		if err := validateMaxResolution(opts); err != nil {
+			return err
+		}
+	}
+
+	if opts.ScaleAlgorithm != "" && !scaleAlgorithms[opts.ScaleAlgorithm] {
+		return fmt.Errorf("unsupported scale algorithm: %s (supported: bilinear, bicubic, lanczos, spline, neighbor, area)", opts.ScaleAlgorithm)
+	}
+
+	if opts.DeinterlaceMode != "" && !deinterlaceModes[opts.DeinterlaceMode] {
+		return fmt.Errorf("unsupported deinterlace mode: %s (supported: yadif, bwdif)", opts.DeinterlaceMode)
+	}
+
+	if opts.Level != "" && !levelRegex.MatchString(opts.Level) {
+		return fmt.Errorf("invalid level: %s (examples: 3.1, 4.1, 5)", opts.Level)
+	}
+
+	if opts.ColorSpace != "" {
+		if err := validateColorSpace(opts.ColorSpace); err != nil {
+			return err
+		}
+	}
+
+	if opts.AspectRatio != "" && !aspectRatioRegex.MatchString(opts.AspectRatio) {
+		return fmt.Errorf("invalid aspect ratio: %s (examples: 16:9, 4:3, 2.35)", opts.AspectRatio)
+	}
+
+	if opts.HWDecode != "" {
+		if err := c.validateHWDecode(opts.HWDecode); err != nil {
+			return err
+		}
+	}
+
+	return validateCombinations(opts)
+}
+
+var containerCodecs = map[string][]string{
+	"mp4":  {"libx264", "libx265", "libvpx-vp9"},
+	"mkv":  {"libx264", "libx265", "libvpx-vp9"},
+	"webm": {"libvpx-vp9"},
+	"avi":  {"libx264"},
+	"mov":  {"libx264", "libx265"},
+}
+
+// validateCombinations rejects option combinations that are individually
+// valid but would make ffmpeg fail or produce a broken file.
+func validateCombinations(opts *Options) error {
+	codec := videoCodec(opts)
+	if allowed, ok := containerCodecs[opts.Format]; ok && !slices.Contains(allowed, codec) {
+		return fmt.Errorf("codec %s cannot be stored in a %s container", opts.Codec, opts.Format)
+	}
+
+	if opts.Profile != "" {
+		profiles, ok := codecProfiles[codec]
+		if !ok {
+			return fmt.Errorf("--profile is only supported with h264 and h265")
+		}
+		if !slices.Contains(profiles, opts.Profile) {
+			return fmt.Errorf("unsupported profile for %s: %s (supported: %s)", codec, opts.Profile, strings.Join(profiles, ", "))
+		}
+	}
+
+	if opts.Level != "" {
+		if _, ok := codecProfiles[codec]; !ok {
+			return fmt.Errorf("--level is only supported with h264 and h265")
+		}
+	}
+
+	if opts.Tune != "" {
+		tunes, ok := codecTunes[codec]
+		if !ok {
+			return fmt.Errorf("--tune is only supported with h264 and h265")
+		}
+		if !slices.Contains(tunes, opts.Tune) {
+			return fmt.Errorf("unsupported tune for %s: %s (supported: %s)", codec, opts.Tune, strings.Join(tunes, ", "))
+		}
+	}
+
+	if opts.ReplaceAudio != "" {
+		if _, err := os.Stat(opts.ReplaceAudio); os.IsNotExist(err) {
+			return fmt.Errorf("replacement audio file does not exist: %s", opts.ReplaceAudio)
+		}
+		if opts.DefaultAudioTrack != nil {
+			return fmt.Errorf("--replace-audio cannot be combined with --default-audio-track")
+		}
+		if opts.MapAll {
+			return fmt.Errorf("--replace-audio cannot be combined with --map-all")
+		}
+	}
+
+	if opts.Deterministic && opts.Threads > 1 && nondeterministicEncoders[videoCodec(opts)] {
+		return fmt.Errorf("--deterministic cannot be combined with --threads for %s, which is only reproducible single-threaded", videoCodec(opts))
+	}
+
+	if opts.Interlace != "" {
+		if err := validateInterlace(opts); err != nil {
+			return err
+		}
+	}
+
+	// The input is looped before it is trimmed: --sample and --max-duration
+	// cut the looped result, but a start offset would only skip into the
+	// first repetition.
+	if opts.Loop > 0 && opts.Start > 0 {
+		return fmt.Errorf("--loop cannot be combined with --start (trim the clip first, then loop it)")
+	}
+
+	if opts.DropDuplicates && opts.ConstantFrameRate {
+		return fmt.Errorf("--dedup cannot be combined with --cfr")
+	}
+
+	if opts.Audio != "" {
+		if err := validateStillImage(opts); err != nil {
+			return err
+		}
+	} else if isStillImage(opts) {
+		return fmt.Errorf("an image input needs --audio for the soundtrack (to export frames use the frames command)")
+	}
+
+	if opts.Chapters != "" {
+		if err := validateChapters(opts); err != nil {
+			return err
+		}
+	}
+
+	if err := validateLanguages("--audio-lang", opts.AudioLanguages); err != nil {
+		return err
+	}
+	if err := validateLanguages("--subtitle-lang", opts.SubtitleLanguages); err != nil {
+		return err
+	}
+
+	if opts.BackgroundAudio != "" {
+		if err := validateBackgroundAudio(opts); err != nil {
+			return err
+		}
+	}
+
+	if len(opts.Subtitles) > 0 {
+		if err := validateSubtitleTracks(opts); err != nil {
+			return err
+		}
+	}
+
+	if err := validateMKVOptions(opts); err != nil {
+		return err
+	}
+
+	if opts.OutputDir != "" {
+		if info, err := os.Stat(opts.OutputDir); err != nil || !info.IsDir() {
+			return fmt.Errorf("output directory does not exist: %s", opts.OutputDir)
+		}
+		if opts.InPlace {
+			return fmt.Errorf("--in-place cannot be combined with --output-dir")
+		}
+	}
+
+	if len(opts.Renditions) > 0 {
+		if err := validateRenditions(opts); err != nil {
+			return err
+		}
+	}
+
+	if !opts.InPlace && samePath(opts.Output, opts.Input) {
+		return fmt.Errorf("output %s is the input file (use --in-place to replace it)", opts.Output)
+	}
+
+	if opts.InPlace {
+		if trimExtension(opts.Output) != trimExtension(opts.Input) {
+			return fmt.Errorf("--in-place cannot be combined with --output or --name-template")
+		}
+		if opts.SampleDuration > 0 {
+			return fmt.Errorf("--in-place cannot be combined with --sample")
+		}
+	}
+
+	if opts.SmoothFPS != 0 {
+		switch {
+		case opts.SmoothFPS < 1 || opts.SmoothFPS > 240:
+			return fmt.Errorf("invalid smooth fps: %g (must be between 1 and 240)", opts.SmoothFPS)
+		case opts.DropDuplicates:
+			return fmt.Errorf("--smooth-fps cannot be combined with --dedup")
+		case opts.ConstantFrameRate:
+			return fmt.Errorf("--smooth-fps cannot be combined with --cfr (its output is already constant frame rate)")
+		}
+	}
+
+	if opts.AutoQuality && (opts.RateControl == RateControlBitrate || opts.VideoBitrate != "") {
+		return fmt.Errorf("--auto-quality only applies to crf rate control")
+	}
+
+	if opts.Quality == QualityLossless && (opts.RateControl == RateControlBitrate || opts.VideoBitrate != "") {
+		return fmt.Errorf("lossless quality cannot be combined with bitrate rate control")
+	}
+
+	if opts.Quality == QualityLossless && opts.Profile != "" && videoCodec(opts) == "libx264" {
+		return fmt.Errorf("lossless quality cannot be combined with --profile for h264 (lossless needs the High 4:4:4 profile)")
+	}
+
+	return nil
+}
+
+func ResolveOutput(opts *Options) {
+	defaultConverter.ResolveOutput(opts)
+}
+
+func (c *Converter) ResolveOutput(opts *Options) {
+	if opts.Output != "" && opts.Format == "" {
+		opts.Format = getExtension(opts.Output)
+	}
+
+	applyPresetBundle(opts)
+
+	if opts.InPlace && opts.Output == "" {
+		if opts.Format == "" {
+			// Keep the container the file really is, even if misnamed.
+			var src *ProbeInfo
+			if !opts.SkipProbe {
+				src, _ = c.Probe(opts.Input)
+			}
+			opts.Format = inputExtension(opts.Input, src)
+		}
+		opts.Output = trimExtension(opts.Input) + "." + opts.Format
+	}
+
+	if opts.Format == "" {
+		opts.Format = "mp4"
+	}
+
+	if opts.Quality == "" {
+		opts.Quality = QualityMedium
+	}
+
+	c.applyEncoderFallback(opts)
+
+	if opts.Output == "" && opts.NameTemplate != "" && validateNameTemplate(opts.NameTemplate) == nil {
+		opts.Output = c.expandNameTemplate(opts)
+	} else if opts.Output == "" {
+		suffix := "_converted"
+		if opts.SampleDuration > 0 {
+			suffix = "_sample"
+		}
+		opts.Output = trimExtension(opts.Input) + suffix + "." + opts.Format
+	} else if opts.SampleDuration > 0 {
+		base := trimExtension(opts.Output)
+		if !strings.HasSuffix(base, "_sample") {
+			opts.Output = base + "_sample" + opts.Output[len(base):]
+		}
+	}
+
+	if opts.OutputDir != "" && !opts.InPlace {
+		opts.Output = filepath.Join(opts.OutputDir, filepath.Base(opts.Output))
+	}
+}
+
+func (c *Converter) Run(opts *Options, onProgress ProgressFunc) error {
+	return c.RunContext(context.Background(), opts, onProgress)
 }
 
-type Options struct {
-	Input      string
-	Output     string
-	Format     string
-	Quality    Quality
-	Resolution string
-	Codec      string
-}
+// RunContext converts opts.Input, stopping ffmpeg when ctx is cancelled or
+// the process receives SIGINT/SIGTERM. An interrupted run removes its
+// temporary files and the partially written output.
+func (c *Converter) RunContext(ctx context.Context, opts *Options, onProgress ProgressFunc) error {
+	if len(opts.Renditions) > 0 {
+		_, err := c.RunLadder(ctx, opts, opts.Renditions, onProgress)
+		return err
+	}
 
-type ProgressFunc func(percent float64)
+	if err := c.Prepare(opts); err != nil {
+		return err
+	}
 
-func CheckFFmpeg() error {
-	_, err := exec.LookPath("ffmpeg")
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	temps := &cleanup{}
+	defer temps.removeAll()
+
+	// The run changes opts as it goes (chapter files, measurements, the
+	// temporary in-place output); the caller gets them back as prepared.
+	prepared := *opts
+	defer func() { *opts = prepared }()
+
+	src, err := c.prepareRun(ctx, opts, temps)
+	if err != nil {
+		return err
+	}
+
+	// Read now: an in-place conversion replaces the input.
+	stamp, err := sourceTimestamp(opts, src)
+	if err != nil {
+		return err
+	}
+
+	final := opts.Output
+	if opts.InPlace {
+		tmp, err := temps.createTemp(filepath.Dir(final), ".fk-converter-*."+opts.Format)
+		if err != nil {
+			return fmt.Errorf("failed to create temporary output: %w", err)
+		}
+		tmp.Close()
+		opts.Output = tmp.Name()
+	}
+
+	if opts.MaxSize > 0 {
+		err = c.encodeToFit(ctx, opts, src, onProgress)
+	} else {
+		err = c.encode(ctx, opts, src, onProgress)
+	}
 	if err != nil {
-		return fmt.Errorf("ffmpeg not found in PATH. Install it:\n  macOS:  brew install ffmpeg\n  Ubuntu: sudo apt install ffmpeg\n  Windows: https://ffmpeg.org/download.html")
+		return err
+	}
+
+	return c.finishRun(ctx, opts, src, stamp, final)
+}
+
+// encode runs the ffmpeg conversion of opts into opts.Output, removing the
+// partial output if interrupted.
+func (c *Converter) encode(ctx context.Context, opts *Options, src *ProbeInfo, onProgress ProgressFunc) error {
+	args := buildFFmpegArgs(opts, src)
+
+	report := progressReporter(onProgress, opts.OnProgressDetail)
+	total := conversionTotal(opts, src)
+	if opts.Audio != "" {
+		total = c.stillImageTotal(opts)
+	}
+
+	if err := c.runFFmpegNiced(ctx, opts.Nice, args, total, report); err != nil {
+		if ctx.Err() != nil {
+			os.Remove(opts.Output)
+			return fmt.Errorf("conversion interrupted: %w", ctx.Err())
+		}
+		return fmt.Errorf("ffmpeg conversion failed: %w", err)
 	}
 	return nil
 }
 
-func ValidateOptions(opts *Options) error {
-	if _, err := os.Stat(opts.Input); os.IsNotExist(err) {
-		return fmt.Errorf("input file does not exist: %s", opts.Input)
+// finishRun completes the encoded output at opts.Output: it verifies it,
+// extracts the audio, moves an in-place output to final over the input and
+// gives the results the source's timestamp (unless zero) and FileMode.
+func (c *Converter) finishRun(ctx context.Context, opts *Options, src *ProbeInfo, stamp time.Time, final string) error {
+	if opts.Verify || opts.VerifyFull {
+		if err := c.verifyOutput(ctx, opts, src); err != nil {
+			return err
+		}
 	}
 
-	if opts.Format != "" && !supportedFormats[opts.Format] {
-		return fmt.Errorf("unsupported format: %s (supported: mp4, mkv, webm, avi, mov)", opts.Format)
+	// Before an in-place replacement, while the source is still there.
+	if opts.ExtractAudio != "" {
+		if err := c.extractAudio(ctx, opts, src); err != nil {
+			if ctx.Err() != nil {
+				os.Remove(opts.ExtractAudio)
+			}
+			return err
+		}
 	}
 
-	if opts.Codec != "" {
-		if _, ok := codecMap[opts.Codec]; !ok {
-			return fmt.Errorf("unsupported codec: %s (supported: h264, h265, vp9)", opts.Codec)
+	if opts.InPlace {
+		if err := replaceInput(opts.Input, opts.Output, final); err != nil {
+			return err
 		}
 	}
 
-	if opts.Quality != "" {
-		if _, ok := crfMap[opts.Quality]; !ok {
-			return fmt.Errorf("unsupported quality: %s (supported: low, medium, high, lossless)", opts.Quality)
+	if !stamp.IsZero() {
+		for _, path := range []string{final, opts.ExtractAudio} {
+			if path == "" {
+				continue
+			}
+			if err := os.Chtimes(path, stamp, stamp); err != nil {
+				return fmt.Errorf("failed to set timestamps: %w", err)
+			}
 		}
 	}
 
-	if opts.Resolution != "" {
-		if !isValidResolution(opts.Resolution) {
-			return fmt.Errorf("invalid resolution: %s (examples: 1080p, 720p, 480p, or 1920x1080)", opts.Resolution)
+	if opts.FileMode != 0 {
+		for _, path := range []string{final, opts.ExtractAudio} {
+			if path == "" {
+				continue
+			}
+			if err := os.Chmod(path, opts.FileMode); err != nil {
+				return fmt.Errorf("failed to set permissions: %w", err)
+			}
 		}
 	}
 
 	return nil
 }
 
-func ResolveOutput(opts *Options) {
-	if opts.Output != "" && opts.Format == "" {
-		parts := strings.Split(opts.Output, ".")
-		if len(parts) > 1 {
-			opts.Format = parts[len(parts)-1]
+// prepareRun probes the input and runs the passes that come before the
+// encode: interlace and scene detection, loudness measurement and chapter
+// metadata. Their results are stored in opts; temporary files go to temps.
+// It returns the probe, nil with SkipProbe.
+func (c *Converter) prepareRun(ctx context.Context, opts *Options, temps *cleanup) (*ProbeInfo, error) {
+	src, err := c.probeInput(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Start > 0 && src != nil && src.Duration > 0 && opts.Start >= src.Duration {
+		return nil, fmt.Errorf("start time %s is past the end of %s (%s)", opts.Start, opts.Input, src.Duration.Round(time.Millisecond))
+	}
+
+	if err := validateMuteRanges(opts, src); err != nil {
+		return nil, err
+	}
+	if err := checkChannelMapSource(opts, src); err != nil {
+		return nil, err
+	}
+
+	// A remux only copies streams, so it needs no encoder.
+	if !canRemux(opts, src) {
+		if err := c.validateEncoder(opts); err != nil {
+			return nil, invalidOptions(err)
 		}
 	}
 
-	if opts.Format == "" {
-		opts.Format = "mp4"
+	c.checkWarnings(opts, src)
+
+	if opts.DetectInterlace && !opts.Deinterlace {
+		interlaced, err := c.DetectInterlace(opts.Input)
+		if err != nil {
+			return nil, err
+		}
+		opts.Deinterlace = interlaced
 	}
 
-	if opts.Output == "" {
-		base := strings.TrimSuffix(opts.Input, "."+getExtension(opts.Input))
-		opts.Output = base + "_converted." + opts.Format
+	if opts.LoudnessTwoPass {
+		measured, err := c.measureLoudness(ctx, opts, src)
+		if err != nil {
+			return nil, err
+		}
+		opts.loudness = measured
 	}
 
-	if opts.Quality == "" {
-		opts.Quality = QualityMedium
+	if opts.SceneDetect > 0 {
+		if src != nil && src.VideoStream() == nil {
+			return nil, fmt.Errorf("--scene-detect: input has no video: %s", opts.Input)
+		}
+		cuts, err := c.detectScenes(ctx, opts.Input, opts.SceneDetect, opts.Start, outputLimit(opts, src), opts.Nice)
+		if err != nil {
+			return nil, err
+		}
+		opts.sceneCuts = cuts
+	}
+
+	// A trimmed output gets the source's chapters within the kept range.
+	if opts.Chapters == "" && src != nil && len(src.Chapters) > 0 && chapterFormats[opts.Format] {
+		if limit := outputLimit(opts, src); opts.Start > 0 || limit > 0 {
+			meta, err := trimChapterMetadata(src.Chapters, opts.Start, limit, opts.AccurateSeek, temps)
+			if err != nil {
+				return nil, err
+			}
+			opts.Chapters = meta
+		}
+	}
+
+	if opts.Chapters != "" {
+		meta, err := writeChapterMetadata(opts.Chapters, conversionTotal(opts, src).duration, temps)
+		if err != nil {
+			return nil, err
+		}
+		opts.Chapters = meta
 	}
+
+	return src, nil
 }
 
-func Convert(opts *Options, onProgress ProgressFunc) error {
-	totalDuration, err := probeDuration(opts.Input)
-	if err != nil {
-		totalDuration = 0
+// replaceInput moves the converted file at tmp over the input. When the
+// format changed, the result gets the new extension and the original file
+// is removed afterwards.
+func replaceInput(input, tmp, final string) error {
+	if err := os.Rename(tmp, final); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", input, err)
+	}
+	if final != input {
+		if err := os.Remove(input); err != nil {
+			return fmt.Errorf("converted to %s but failed to remove %s: %w", final, input, err)
+		}
 	}
+	return nil
+}
 
-	args := buildFFmpegArgs(opts)
+// runFFmpeg runs ffmpeg with args, which must include -progress pipe:2,
+// reporting progress against total until it exits. report may be nil.
+func (c *Converter) runFFmpeg(ctx context.Context, args []string, total progressTotal, report func(Progress)) error {
+	return c.runFFmpegNiced(ctx, 0, args, total, report)
+}
 
-	cmd := exec.Command("ffmpeg", args...)
-	cmd.Stdout = nil
+// runFFmpegNiced is runFFmpeg at the given niceness (see Options.Nice).
+func (c *Converter) runFFmpegNiced(ctx context.Context, nice int, args []string, total progressTotal, report func(Progress)) error {
+	cmd := exec.CommandContext(ctx, c.ffmpeg(), args...)
 
 	stderr, err := cmd.StderrPipe()
 	if err != nil {
@@ -130,54 +1136,436 @@ func Convert(opts *Options, onProgress ProgressFunc) error {
 	}
 
 	if err := cmd.Start(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return fmt.Errorf("failed to start ffmpeg: %w", ErrFFmpegNotFound)
+		}
 		return fmt.Errorf("failed to start ffmpeg: %w", err)
 	}
+	c.lowerPriority(cmd, nice)
 
-	if onProgress != nil && totalDuration > 0 {
-		parseProgress(stderr, totalDuration, onProgress)
-	} else {
-		io.Copy(io.Discard, stderr)
+	tail := &stderrTail{}
+	var log io.Writer = tail
+	if c.Logger != nil {
+		log = io.MultiWriter(c.Logger, tail)
 	}
+	parseProgress(stderr, total, report, c.OnStats, log)
 
 	if err := cmd.Wait(); err != nil {
-		return fmt.Errorf("ffmpeg conversion failed: %w", err)
+		convErr := &ConversionError{ExitCode: -1, Stderr: tail.String(), Err: err}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			convErr.ExitCode = exitErr.ExitCode()
+		}
+		return convErr
+	}
+	return nil
+}
+
+// conversionTotal works out how much output a conversion of opts will
+// produce, accounting for looping, the start offset, sampling and the
+// duration cap.
+func conversionTotal(opts *Options, src *ProbeInfo) progressTotal {
+	total := requestedTotal(opts, src)
+	if src != nil && opts.MaxDuration > 0 {
+		total = limitTotal(total, opts.MaxDuration, src)
 	}
+	return total
+}
+
+// requestedTotal is conversionTotal before MaxDuration is applied.
+func requestedTotal(opts *Options, src *ProbeInfo) progressTotal {
+	if src == nil {
+		return progressTotal{}
+	}
+
+	total := progressTotal{duration: src.Duration, frames: src.TotalFrames()}
+	total.duration *= time.Duration(opts.Loop + 1)
+	total.frames *= int64(opts.Loop + 1)
+
+	if opts.Start > 0 {
+		total.duration = max(total.duration-opts.Start, 0)
+		if v := src.VideoStream(); v != nil && v.FrameRate > 0 {
+			total.frames = max(total.frames-int64(opts.Start.Seconds()*v.FrameRate), 0)
+		}
+	}
+
+	if opts.SampleDuration > 0 {
+		total = limitTotal(total, opts.SampleDuration, src)
+	}
+	return total
+}
+
+// limitTotal caps total at d of output.
+func limitTotal(total progressTotal, d time.Duration, src *ProbeInfo) progressTotal {
+	total.duration = min(total.duration, d)
+	if v := src.VideoStream(); v != nil && v.FrameRate > 0 {
+		total.frames = min(total.frames, int64(d.Seconds()*v.FrameRate))
+	}
+	return total
+}
+
+// exceedsMaxDuration reports whether the output would run longer than
+// MaxDuration. Without a probe, or with a still image whose length is set
+// by the audio, it can't tell and assumes so: cutting at a point past the
+// end changes nothing.
+func exceedsMaxDuration(opts *Options, src *ProbeInfo) bool {
+	if opts.MaxDuration <= 0 {
+		return false
+	}
+	if src == nil || opts.Audio != "" {
+		return true
+	}
+	return requestedTotal(opts, src).duration > opts.MaxDuration
+}
+
+// outputLimit is the -t length of the output: MaxDuration when the output
+// would run longer, or else the sample length.
+func outputLimit(opts *Options, src *ProbeInfo) time.Duration {
+	if exceedsMaxDuration(opts, src) {
+		return opts.MaxDuration
+	}
+	return opts.SampleDuration
+}
 
+func (c *Converter) extractAudio(ctx context.Context, opts *Options, src *ProbeInfo) error {
+	out, err := exec.CommandContext(ctx, c.ffmpeg(), extractAudioArgs(opts, src)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("audio extraction failed: %w\n%s", err, lastLines(string(out), 5))
+	}
 	return nil
 }
 
-func buildFFmpegArgs(opts *Options) []string {
-	args := []string{"-i", opts.Input, "-y", "-progress", "pipe:2", "-nostats"}
+// extractAudioArgs extracts the selected audio track from the same part of
+// the input as the conversion, looped, trimmed and delayed alike, so it
+// lines up with the converted video.
+func extractAudioArgs(opts *Options, src *ProbeInfo) []string {
+	var args []string
+	if opts.Loop > 0 {
+		args = append(args, "-stream_loop", strconv.Itoa(opts.Loop))
+	}
+	if opts.Start > 0 {
+		args = append(args, "-ss", formatSeconds(opts.Start))
+	}
+	args = append(args, "-i", opts.Input, "-y", "-vn", "-map", audioMap(opts))
+	if limit := outputLimit(opts, src); limit > 0 {
+		args = append(args, "-t", formatSeconds(limit))
+	}
+	if filter := audioDelayFilter(opts.AudioDelay); filter != "" {
+		args = append(args, "-af", filter)
+	}
+
+	format := extractAudioFormat(opts)
+	args = append(args, "-c:a", format.codec)
+	if format.bitrate != "" {
+		args = append(args, "-b:a", format.bitrate)
+	}
+	return append(args, opts.ExtractAudio)
+}
 
-	codec := "libx264"
-	if opts.Codec != "" {
-		codec = codecMap[opts.Codec]
-	} else if opts.Format == "webm" {
-		codec = "libvpx-vp9"
+func audioMap(opts *Options) string {
+	return fmt.Sprintf("0:a:%d", opts.AudioTrack)
+}
+
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// buildFFmpegArgs assembles the ffmpeg command line. src describes the input
+// and may be nil when probing failed.
+func buildFFmpegArgs(opts *Options, src *ProbeInfo) []string {
+	args := buildInputArgs(opts)
+	args = append(args, buildOutputArgs(opts, src)...)
+	args = append(args, extraArgs(opts)...)
+	return append(args, opts.Output)
+}
+
+// extraArgs returns ExtraArgs with the {input} and {output} placeholders
+// filled in.
+func extraArgs(opts *Options) []string {
+	r := strings.NewReplacer("{input}", opts.Input, "{output}", opts.Output)
+	args := make([]string, len(opts.ExtraArgs))
+	for i, arg := range opts.ExtraArgs {
+		args[i] = r.Replace(arg)
+	}
+	return args
+}
+
+func buildInputArgs(opts *Options) []string {
+	var args []string
+	if opts.Loop > 0 {
+		args = append(args, "-stream_loop", strconv.Itoa(opts.Loop))
+	}
+	args = append(args, hwDecodeArgs(opts)...)
+	if opts.ReadRate > 0 {
+		args = append(args, "-readrate", strconv.FormatFloat(opts.ReadRate, 'f', -1, 64))
+	}
+	if opts.Audio != "" {
+		args = append(args, "-loop", "1")
+	}
+	if opts.RotateAuto {
+		// Rotate explicitly with a filter instead.
+		args = append(args, "-noautorotate")
+	}
+	if opts.Start > 0 && !opts.AccurateSeek {
+		args = append(args, "-ss", formatSeconds(opts.Start))
+	}
+	args = append(args, "-i", opts.Input)
+	if opts.Audio != "" {
+		args = append(args, "-i", opts.Audio)
+	}
+	if opts.ReplaceAudio != "" {
+		args = append(args, "-i", opts.ReplaceAudio)
+	}
+	if opts.BackgroundAudio != "" {
+		args = append(args, "-stream_loop", "-1", "-i", opts.BackgroundAudio)
+	}
+	if opts.Chapters != "" {
+		args = append(args, "-i", opts.Chapters)
+	}
+	args = append(args, subtitleInputArgs(opts)...)
+	return append(args, "-y", "-progress", "pipe:2", "-nostats")
+}
+
+// buildOutputArgs returns the options for one output file, excluding its
+// path. Several sets can follow a single input to encode it more than once
+// from one decode.
+func buildOutputArgs(opts *Options, src *ProbeInfo) []string {
+	var args []string
+	if opts.Start > 0 && opts.AccurateSeek {
+		args = append(args, "-ss", formatSeconds(opts.Start))
+	}
+	if limit := outputLimit(opts, src); limit > 0 {
+		args = append(args, "-t", formatSeconds(limit))
+	}
+
+	if opts.ReplaceAudio != "" {
+		args = append(args, "-map", "0:v:0", "-map", "1:a:0", "-shortest")
+	}
+	if opts.Audio != "" {
+		// The image loops forever; the audio sets the length.
+		args = append(args, "-map", "0:v:0", "-map", "1:a:0", "-shortest")
+	}
+	if opts.BackgroundAudio != "" {
+		args = append(args, backgroundAudioArgs(opts, src)...)
+	}
+
+	if opts.AspectRatio != "" {
+		args = append(args, "-aspect", opts.AspectRatio)
+	}
+
+	if opts.MapAll {
+		args = append(args, mapAllArgs(opts)...)
+		if _, _, capped := cappedSize(opts, src); !needsReencode(opts) && !capped {
+			return append(args, containerArgs(opts, src)...)
+		}
+	}
+	if canRemux(opts, src) {
+		args = append(args, remuxArgs(opts, src)...)
+		return append(args, containerArgs(opts, src)...)
 	}
 
+	codec := videoCodec(opts)
 	args = append(args, "-c:v", codec)
 
-	crf := crfMap[opts.Quality]
-	if strings.Contains(codec, "vpx") {
-		args = append(args, "-crf", strconv.Itoa(crf), "-b:v", "0")
-	} else {
-		args = append(args, "-crf", strconv.Itoa(crf))
+	args = append(args, videoRateArgs(opts, codec, src)...)
+	if opts.Audio != "" {
+		args = append(args, stillImageArgs(opts, codec)...)
 	}
 
-	args = append(args, "-c:a", "aac", "-b:a", "128k")
+	args = append(args, keyframeArgs(opts)...)
+	args = append(args, interlaceArgs(opts)...)
+	args = append(args, videoFlagsArgs(opts)...)
 
-	if opts.Resolution != "" {
-		scale := resolveScale(opts.Resolution)
-		args = append(args, "-vf", scale)
+	if opts.DropDuplicates {
+		args = append(args, "-vsync", "vfr")
+	}
+	if opts.ConstantFrameRate {
+		args = append(args, "-vsync", "cfr")
+		if v := src.VideoStream(); v != nil && v.FrameRate > 0 {
+			args = append(args, "-r", strconv.FormatFloat(v.FrameRate, 'f', 3, 64))
+		}
+	}
+
+	if opts.Profile != "" {
+		args = append(args, "-profile:v", opts.Profile)
+	}
+	if opts.Level != "" {
+		args = append(args, "-level", opts.Level)
+	}
+	if opts.Tune != "" {
+		args = append(args, "-tune", opts.Tune)
+	}
+	if opts.Threads > 0 {
+		args = append(args, "-threads", strconv.Itoa(opts.Threads))
+	}
+	if opts.Deterministic {
+		args = append(args, deterministicEncoderArgs(opts, codec)...)
+	}
+	if codec == "libx264" && opts.X264Params != "" {
+		args = append(args, "-x264-params", opts.X264Params)
+	}
+	if params := x265Params(opts); codec == "libx265" && params != "" {
+		args = append(args, "-x265-params", params)
+	}
+
+	args = append(args, audioCodecArgs(opts, src)...)
+	if opts.SampleRate > 0 {
+		args = append(args, "-ar", strconv.Itoa(opts.SampleRate))
+	} else if rate := sourceSampleRate(src); opts.TargetLUFS != 0 && rate > 0 {
+		// loudnorm resamples to 192 kHz internally.
+		args = append(args, "-ar", strconv.Itoa(rate))
+	}
+
+	args = append(args, colorTagArgs(opts)...)
+	if opts.RotateAuto {
+		args = append(args, "-metadata:s:v:0", "rotate=0")
 	}
 
-	args = append(args, opts.Output)
+	if filters := buildVideoFilters(opts, src); len(filters) > 0 {
+		args = append(args, "-vf", strings.Join(filters, ","))
+	}
+	if filters := buildAudioFilters(opts, src); len(filters) > 0 && opts.BackgroundAudio == "" {
+		args = append(args, "-af", strings.Join(filters, ","))
+	}
+
+	return append(args, containerArgs(opts, src)...)
+}
+
+// containerArgs returns the muxer options, which apply whether streams are
+// encoded or copied.
+func containerArgs(opts *Options, src *ProbeInfo) []string {
+	args := mkvArgs(opts)
+	if opts.Chapters != "" {
+		args = append(args, "-map_chapters", strconv.Itoa(chapterInputIndex(opts)))
+	}
+	args = append(args, languageArgs(opts)...)
+	args = append(args, subtitleTrackArgs(opts, src)...)
+	if opts.Deterministic {
+		args = append(args, "-map_metadata", "-1", "-fflags", "+bitexact")
+	}
+	if opts.Fragmented {
+		args = append(args, "-movflags", "frag_keyframe+empty_moov+default_base_moof")
+	} else if fastStartFormats[opts.Format] && !opts.NoFastStart {
+		args = append(args, "-movflags", "+faststart")
+	}
 	return args
 }
 
-func probeDuration(input string) (time.Duration, error) {
-	cmd := exec.Command("ffprobe",
+// keyframeArgs caps the GOP at KeyframeInterval frames and, for segmented
+// streaming, forces a keyframe every KeyframeEvery so segment boundaries
+// always land on one, or at every scene change found with SceneDetect.
+func keyframeArgs(opts *Options) []string {
+	var args []string
+	if opts.KeyframeInterval > 0 {
+		gop := strconv.Itoa(opts.KeyframeInterval)
+		args = append(args, "-g", gop, "-keyint_min", gop)
+	}
+	if opts.KeyframeEvery > 0 {
+		args = append(args, "-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%s)", formatSeconds(opts.KeyframeEvery)))
+	}
+	return append(args, sceneKeyframeArgs(opts.sceneCuts)...)
+}
+
+func buildVideoFilters(opts *Options, src *ProbeInfo) []string {
+	var filters []string
+	if opts.Deinterlace {
+		filters = append(filters, deinterlaceFilter(opts))
+	}
+	if filter := rotationFilter(opts, src); filter != "" {
+		filters = append(filters, filter)
+	}
+	if opts.Resolution != "" {
+		filters = append(filters, resolveScale(opts.Resolution, opts.ScaleAlgorithm))
+	}
+	if filter := maxResolutionFilter(opts, src); filter != "" {
+		filters = append(filters, filter)
+	}
+	if opts.DropDuplicates {
+		filters = append(filters, "mpdecimate")
+	}
+	if opts.SmoothFPS > 0 {
+		filters = append(filters, smoothFPSFilter(opts.SmoothFPS))
+	}
+	if filter := colorSpaceFilter(opts, src); filter != "" {
+		filters = append(filters, filter)
+	}
+	if opts.CustomVideoFilter != "" {
+		filters = append(filters, opts.CustomVideoFilter)
+	}
+	// Last: any filter after it would process the fields as frames.
+	if opts.Interlace != "" {
+		filters = append(filters, interlaceFilter(opts))
+	}
+	return filters
+}
+
+// smoothFPSFilter interpolates motion-compensated frames to reach fps,
+// with ffmpeg's recommended quality settings.
+func smoothFPSFilter(fps float64) string {
+	return "minterpolate=fps=" + strconv.FormatFloat(fps, 'f', -1, 64) + ":mi_mode=mci:mc_mode=aobmc:me_mode=bidir:vsbmc=1"
+}
+
+func buildAudioFilters(opts *Options, src *ProbeInfo) []string {
+	filters := preLoudnessFilters(opts, src)
+	if opts.TargetLUFS != 0 {
+		filters = append(filters, loudnormFilter(opts, opts.loudness))
+	}
+	if opts.CustomAudioFilter != "" {
+		filters = append(filters, opts.CustomAudioFilter)
+	}
+	return filters
+}
+
+// preLoudnessFilters are the generated audio filters that run before
+// loudness normalization, which measures their result.
+func preLoudnessFilters(opts *Options, src *ProbeInfo) []string {
+	filters := muteFilters(opts)
+	if filter := channelMapFilter(opts, src); filter != "" {
+		filters = append(filters, filter)
+	}
+	if filter := audioDelayFilter(opts.AudioDelay); filter != "" {
+		filters = append(filters, filter)
+	}
+	if opts.DownmixStereo {
+		if filter := downmixFilter(src); filter != "" {
+			filters = append(filters, filter)
+		}
+	}
+	return filters
+}
+
+// audioDelayFilter pads the start of the audio with silence to delay it,
+// or trims it to make it play earlier.
+func audioDelayFilter(delay time.Duration) string {
+	switch {
+	case delay > 0:
+		return fmt.Sprintf("adelay=delays=%d:all=1", delay.Milliseconds())
+	case delay < 0:
+		return fmt.Sprintf("atrim=start=%s,asetpts=PTS-STARTPTS", formatSeconds(-delay))
+	}
+	return ""
+}
+
+func videoCodec(opts *Options) string {
+	if opts.Codec != "" {
+		return codecMap[opts.Codec]
+	}
+	if opts.Format == "webm" {
+		return "libvpx-vp9"
+	}
+	return "libx264"
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
+
+func (c *Converter) probeDuration(input string) (time.Duration, error) {
+	cmd := exec.Command(c.ffprobe(),
 		"-v", "error",
 		"-show_entries", "format=duration",
 		"-of", "default=noprint_wrappers=1:nokey=1",
@@ -196,26 +1584,48 @@ func probeDuration(input string) (time.Duration, error) {
 	return time.Duration(seconds * float64(time.Second)), nil
 }
 
-var timeRegex = regexp.MustCompile(`out_time_us=(\d+)`)
-
-func parseProgress(r io.Reader, total time.Duration, onProgress ProgressFunc) {
-	scanner := bufio.NewScanner(r)
-	for scanner.Scan() {
-		line := scanner.Text()
-		matches := timeRegex.FindStringSubmatch(line)
-		if len(matches) == 2 {
-			us, err := strconv.ParseInt(matches[1], 10, 64)
-			if err != nil {
-				continue
-			}
-			current := time.Duration(us) * time.Microsecond
-			percent := float64(current) / float64(total) * 100
-			if percent > 100 {
-				percent = 100
-			}
-			onProgress(percent)
+// applyDefaults copies every field left at its zero value in opts from
+// defaults.
+func applyDefaults(opts, defaults *Options) {
+	dst := reflect.ValueOf(opts).Elem()
+	src := reflect.ValueOf(defaults).Elem()
+	for i := 0; i < dst.NumField(); i++ {
+		// Unexported fields are state of a running conversion.
+		if !dst.Type().Field(i).IsExported() {
+			continue
 		}
+		if dst.Field(i).IsZero() {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+}
+
+func validateFormat(format string) error {
+	if format != "" && !supportedFormats[format] {
+		return fmt.Errorf("%w: %s (supported: mp4, mkv, webm, avi, mov)", ErrUnsupportedFormat, format)
+	}
+	return nil
+}
+
+func validateCodec(codec string) error {
+	if _, ok := codecMap[codec]; codec != "" && !ok {
+		return fmt.Errorf("unsupported codec: %s (supported: h264, h265, vp9)", codec)
+	}
+	return nil
+}
+
+func validateQuality(quality Quality) error {
+	if _, ok := crfMap[quality]; quality != "" && !ok {
+		return fmt.Errorf("unsupported quality: %s (supported: low, medium, high, lossless)", quality)
 	}
+	return nil
+}
+
+func validateResolution(res string) error {
+	if res != "" && !isValidResolution(res) {
+		return fmt.Errorf("invalid resolution: %s (examples: 1080p, 720p, 480p, or 1920x1080)", res)
+	}
+	return nil
 }
 
 func isValidResolution(res string) bool {
@@ -230,7 +1640,17 @@ func isValidResolution(res string) bool {
 	return matched
 }
 
-func resolveScale(res string) string {
+// resolveScale returns the scale filter for res, using the given scaler
+// algorithm if not empty.
+func resolveScale(res, algorithm string) string {
+	scale := scaleFilter(res)
+	if algorithm != "" {
+		scale += ":flags=" + algorithm
+	}
+	return scale
+}
+
+func scaleFilter(res string) string {
 	presets := map[string]string{
 		"2160p": "scale=-2:2160",
 		"1440p": "scale=-2:1440",
@@ -246,10 +1666,34 @@ func resolveScale(res string) string {
 	return fmt.Sprintf("scale=%s:%s", parts[0], parts[1])
 }
 
+// samePath reports whether a and b name the same file, also through links
+// when it exists.
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA == nil && errB == nil && absA == absB {
+		return true
+	}
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
+
+// getExtension returns the extension of the last path element without the
+// dot. Dots in directory names and leading dots of hidden files don't count.
 func getExtension(filename string) string {
-	parts := strings.Split(filename, ".")
-	if len(parts) > 1 {
-		return parts[len(parts)-1]
+	base := filepath.Base(filename)
+	ext := filepath.Ext(base)
+	if ext == base {
+		return ""
 	}
-	return ""
+	return strings.TrimPrefix(ext, ".")
+}
+
+func trimExtension(filename string) string {
+	ext := getExtension(filename)
+	if ext == "" {
+		return filename
+	}
+	return strings.TrimSuffix(filename, "."+ext)
 }
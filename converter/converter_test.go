@@ -0,0 +1,434 @@
+package converter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeFFmpegScript writes its arguments next to the output (the last
+// argument) and fills the output, reporting progress like ffmpeg does.
+// An output pattern such as frame_%04d.png gets three images numbered
+// from -start_number.
+// FAKE_FFMPEG_FAIL makes it fail the way a real conversion would,
+// FAKE_FFMPEG_ENCODERS replaces its encoder list and FAKE_ENCODERS_LOG
+// counts the times it is listed.
+const fakeFFmpegScript = `#!/bin/sh
+case "$*" in
+*-version*) echo "ffmpeg version 7.1"; exit 0 ;;
+*-encoders*)
+	[ -n "$FAKE_ENCODERS_LOG" ] && echo listed >> "$FAKE_ENCODERS_LOG"
+	printf "${FAKE_FFMPEG_ENCODERS:- V..... libx264\n V..... libx265\n V..... libvpx-vp9\n}"
+	exit 0 ;;
+esac
+if [ -n "$FAKE_FFMPEG_FAIL" ]; then
+	echo "in.mov: Invalid data found when processing input" >&2
+	exit 1
+fi
+for arg; do
+	[ "$prev" = -start_number ] && start=$arg
+	prev=$arg out=$arg
+done
+printf 'frame=48\nout_time_us=2000000\nprogress=end\n' >&2
+case "$out" in
+*%*)
+	for n in $start $((start + 1)) $((start + 2)); do
+		echo frame > "$(printf "$out" $n)"
+	done
+	exit 0 ;;
+esac
+echo "$*" > "$out.args"
+echo converted > "$out"
+`
+
+const fakeFFprobeOutput = `{
+  "format": {
+    "format_name": "${FAKE_FFPROBE_FORMAT:-mov,mp4,m4a,3gp,3g2,mj2}",
+    "duration": "2.000000",
+    "size": "1048576",
+    "bit_rate": "4194304",
+    "tags": {"creation_time": "2024-05-01T10:30:00.000000Z"}
+  },
+  "streams": [
+    {
+      "index": 0, "codec_type": "video", "codec_name": "h264",
+      "width": 1920, "height": 1080,
+      "avg_frame_rate": "24/1", "r_frame_rate": "24/1", "nb_frames": "48",
+      "pix_fmt": "yuv420p",
+      "side_data_list": [{"rotation": -90}]
+    },
+    {
+      "index": 1, "codec_type": "audio", "codec_name": "aac",
+      "channels": 2, "sample_rate": "48000",
+      "tags": {"language": "eng"}
+    }
+  ],
+  "chapters": [
+    {"start_time": "0.000000", "end_time": "1.500000", "tags": {"title": "Intro"}}
+  ]
+}`
+
+// fakeFFmpeg puts fake ffmpeg and ffprobe binaries first on PATH. The
+// probed container can be changed with FAKE_FFPROBE_FORMAT.
+func fakeFFmpeg(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake ffmpeg is a shell script")
+	}
+
+	bin := t.TempDir()
+	scripts := map[string]string{
+		"ffmpeg":  fakeFFmpegScript,
+		"ffprobe": "#!/bin/sh\ncat <<EOF\n" + fakeFFprobeOutput + "\nEOF\n",
+	}
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(bin, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// writeInput creates an input file for the fake ffmpeg to convert.
+func writeInput(t *testing.T, name string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte("source"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConverterRun(t *testing.T) {
+	fakeFFmpeg(t)
+	input := writeInput(t, "in.mov")
+
+	c := &Converter{Defaults: &Options{Quality: QualityHigh}}
+	opts := &Options{Input: input, Codec: "h265"}
+
+	var last float64
+	if err := c.Run(opts, func(percent float64) { last = percent }); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	want := strings.TrimSuffix(input, ".mov") + "_converted.mp4"
+	if opts.Output != want {
+		t.Errorf("Output = %q, want %q", opts.Output, want)
+	}
+	if data, err := os.ReadFile(want); err != nil || string(data) != "converted\n" {
+		t.Errorf("output not written: %q, %v", data, err)
+	}
+	if last != 100 {
+		t.Errorf("last progress = %g, want 100", last)
+	}
+
+	args, err := os.ReadFile(want + ".args")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, arg := range []string{"-c:v libx265", "-crf 18", "-i " + input} {
+		if !strings.Contains(string(args), arg) {
+			t.Errorf("ffmpeg args %q lack %q", args, arg)
+		}
+	}
+	if opts.Quality != QualityHigh {
+		t.Errorf("Quality = %q, want the converter default %q", opts.Quality, QualityHigh)
+	}
+}
+
+func TestConverterRunFailure(t *testing.T) {
+	fakeFFmpeg(t)
+	t.Setenv("FAKE_FFMPEG_FAIL", "1")
+	input := writeInput(t, "in.mov")
+
+	var log strings.Builder
+	err := (&Converter{Logger: &log}).Run(&Options{Input: input}, nil)
+
+	var convErr *ConversionError
+	if !errors.As(err, &convErr) {
+		t.Fatalf("Run error = %v, want a *ConversionError", err)
+	}
+	if convErr.ExitCode != 1 {
+		t.Errorf("ExitCode = %d, want 1", convErr.ExitCode)
+	}
+	if !strings.Contains(convErr.Stderr, "Invalid data found") {
+		t.Errorf("Stderr = %q, want ffmpeg's message", convErr.Stderr)
+	}
+	if !strings.Contains(log.String(), "Invalid data found") {
+		t.Errorf("Logger got %q, want ffmpeg's output", log.String())
+	}
+}
+
+func TestConverterRunInvalidOptions(t *testing.T) {
+	fakeFFmpeg(t)
+	input := writeInput(t, "in.mov")
+
+	err := (&Converter{}).Run(&Options{Input: input, Format: "flv"}, nil)
+	if !errors.Is(err, ErrInvalidOptions) {
+		t.Errorf("Run error = %v, want ErrInvalidOptions", err)
+	}
+}
+
+func TestProbe(t *testing.T) {
+	fakeFFmpeg(t)
+
+	info, err := (&Converter{}).Probe("in.mov")
+	if err != nil {
+		t.Fatalf("Probe: %v", err)
+	}
+
+	if info.Duration != 2*time.Second || info.Size != 1048576 || info.BitRate != 4194304 {
+		t.Errorf("format = %s, %d bytes, %d b/s", info.Duration, info.Size, info.BitRate)
+	}
+	if want := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC); !info.CreationTime.Equal(want) {
+		t.Errorf("CreationTime = %s, want %s", info.CreationTime, want)
+	}
+
+	v := info.VideoStream()
+	if v == nil {
+		t.Fatal("no video stream")
+	}
+	if v.Codec != "h264" || v.Width != 1920 || v.Height != 1080 || v.FrameRate != 24 || v.Frames != 48 {
+		t.Errorf("video stream = %+v", *v)
+	}
+	if v.Rotation != 90 {
+		t.Errorf("Rotation = %d, want 90 (display matrix -90 is clockwise 90)", v.Rotation)
+	}
+	if got := info.TotalFrames(); got != 48 {
+		t.Errorf("TotalFrames = %d, want 48", got)
+	}
+
+	audio := info.StreamsOfType("audio")
+	if len(audio) != 1 || audio[0].Channels != 2 || audio[0].SampleRate != 48000 || audio[0].Language != "eng" {
+		t.Errorf("audio streams = %+v", audio)
+	}
+
+	want := []ChapterInfo{{Start: 0, End: 1500 * time.Millisecond, Title: "Intro"}}
+	if len(info.Chapters) != 1 || info.Chapters[0] != want[0] {
+		t.Errorf("Chapters = %+v, want %+v", info.Chapters, want)
+	}
+}
+
+func TestExtension(t *testing.T) {
+	tests := []struct {
+		path, ext, trimmed string
+	}{
+		{"video.mov", "mov", "video"},
+		{"my.video.final.mov", "mov", "my.video.final"},
+		{"archive.tar.mov", "mov", "archive.tar"},
+		{"/home/me/my videos/clip one.mp4", "mp4", "/home/me/my videos/clip one"},
+		{"/data/v1.2/clip", "", "/data/v1.2/clip"},
+		{"/data/v1.2/clip.mkv", "mkv", "/data/v1.2/clip"},
+		{"README", "", "README"},
+		{".hidden", "", ".hidden"},
+		{".hidden.mov", "mov", ".hidden"},
+	}
+	for _, tt := range tests {
+		if got := getExtension(tt.path); got != tt.ext {
+			t.Errorf("getExtension(%q) = %q, want %q", tt.path, got, tt.ext)
+		}
+		if got := trimExtension(tt.path); got != tt.trimmed {
+			t.Errorf("trimExtension(%q) = %q, want %q", tt.path, got, tt.trimmed)
+		}
+	}
+}
+
+func TestResolveOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   Options
+		output string
+		format string
+	}{
+		{"default", Options{Input: "clip.mov"}, "clip_converted.mp4", "mp4"},
+		{"multiple dots", Options{Input: "my.video.final.mov", Format: "mkv"}, "my.video.final_converted.mkv", "mkv"},
+		{"dotted directory", Options{Input: "/data/v1.2/clip"}, "/data/v1.2/clip_converted.mp4", "mp4"},
+		{"spaces", Options{Input: "/my videos/clip one.mov"}, "/my videos/clip one_converted.mp4", "mp4"},
+		{"format from output", Options{Input: "clip.mov", Output: "/out.d/final.webm"}, "/out.d/final.webm", "webm"},
+		{"sample", Options{Input: "clip.mov", SampleDuration: time.Second}, "clip_sample.mp4", "mp4"},
+		{"sample output", Options{Input: "clip.mov", Output: "cut.mp4", SampleDuration: time.Second}, "cut_sample.mp4", "mp4"},
+		{"output dir", Options{Input: "/in/clip.mov", OutputDir: "/out"}, "/out/clip_converted.mp4", "mp4"},
+		{"output dir with output", Options{Input: "/in/clip.mov", Output: "sub/final.mkv", OutputDir: "/out"}, "/out/final.mkv", "mkv"},
+		{"in place", Options{Input: "/in/clip.mov", InPlace: true}, "/in/clip.mov", "mov"},
+		{"in place new format", Options{Input: "/in/clip.mov", Format: "mkv", InPlace: true}, "/in/clip.mkv", "mkv"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			(&Converter{}).ResolveOutput(&opts)
+			if opts.Output != tt.output || opts.Format != tt.format {
+				t.Errorf("output = %q (%s), want %q (%s)", opts.Output, opts.Format, tt.output, tt.format)
+			}
+		})
+	}
+}
+
+func TestValidateOptionsOutputIsInput(t *testing.T) {
+	input := writeInput(t, "clip.mp4")
+	dir := filepath.Dir(input)
+
+	tests := []Options{
+		{Input: input, NameTemplate: "{name}.{ext}"},
+		{Input: input, Output: filepath.Join(dir, ".", "clip.mp4")},
+	}
+	for _, opts := range tests {
+		ResolveOutput(&opts)
+		if err := ValidateOptions(&opts); !errors.Is(err, ErrInvalidOptions) {
+			t.Errorf("output %s: error = %v, want ErrInvalidOptions", opts.Output, err)
+		}
+	}
+
+	opts := Options{Input: input, InPlace: true}
+	ResolveOutput(&opts)
+	if err := ValidateOptions(&opts); err != nil {
+		t.Errorf("--in-place: %v", err)
+	}
+}
+
+func TestRunInPlace(t *testing.T) {
+	fakeFFmpeg(t)
+
+	tests := []struct {
+		name, input, format, output string
+	}{
+		{"same format", "clip.mp4", "", "clip.mp4"},
+		{"new format", "clip.mov", "mkv", "clip.mkv"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := writeInput(t, tt.input)
+			want := filepath.Join(filepath.Dir(input), tt.output)
+			audio := filepath.Join(filepath.Dir(input), "clip.mp3")
+
+			opts := &Options{Input: input, Format: tt.format, InPlace: true, ExtractAudio: audio}
+			if err := (&Converter{}).Run(opts, nil); err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+
+			if data, err := os.ReadFile(want); err != nil || string(data) != "converted\n" {
+				t.Errorf("%s not replaced: %q, %v", want, data, err)
+			}
+			if want != input {
+				if _, err := os.Stat(input); !os.IsNotExist(err) {
+					t.Errorf("original %s not removed: %v", input, err)
+				}
+			}
+			if opts.Output != want {
+				t.Errorf("Output = %q, want %q", opts.Output, want)
+			}
+
+			// The audio comes from the source, which is gone afterwards.
+			args, err := os.ReadFile(audio + ".args")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(args), "-i "+input) {
+				t.Errorf("audio extracted with %q, want it from %s", args, input)
+			}
+
+			leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(input), ".fk-converter-*."+getExtension(want)))
+			if len(leftovers) > 0 {
+				t.Errorf("temporary files left: %v", leftovers)
+			}
+		})
+	}
+}
+
+func TestRunInPlaceFailure(t *testing.T) {
+	fakeFFmpeg(t)
+	t.Setenv("FAKE_FFMPEG_FAIL", "1")
+	input := writeInput(t, "clip.mov")
+
+	opts := &Options{Input: input, Format: "mkv", InPlace: true}
+	if err := (&Converter{}).Run(opts, nil); err == nil {
+		t.Fatal("Run succeeded with a failing ffmpeg")
+	}
+
+	if data, err := os.ReadFile(input); err != nil || string(data) != "source" {
+		t.Errorf("original changed: %q, %v", data, err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(input))
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d files, want only the original", len(entries))
+	}
+}
+
+func TestEncoderCheck(t *testing.T) {
+	fakeFFmpeg(t)
+	t.Setenv("FAKE_FFMPEG_ENCODERS", ` A..... aac\n`)
+	listed := filepath.Join(t.TempDir(), "listed")
+	t.Setenv("FAKE_ENCODERS_LOG", listed)
+	input := writeInput(t, "in.mov")
+	c := &Converter{}
+
+	// A container change copies the streams and needs no encoder.
+	remux := &Options{Input: input}
+	if err := c.Run(remux, nil); err != nil {
+		t.Fatalf("remux without libx264: %v", err)
+	}
+	args, err := os.ReadFile(remux.Output + ".args")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(args), "-c:v copy") {
+		t.Errorf("remux args %q don't copy the streams", args)
+	}
+
+	err = c.Run(&Options{Input: input, Resolution: "720p", Output: filepath.Join(t.TempDir(), "out.mp4")}, nil)
+	if !errors.Is(err, ErrInvalidOptions) || !strings.Contains(err.Error(), "libx264") {
+		t.Errorf("encode without libx264: error = %v, want a missing encoder", err)
+	}
+
+	if data, _ := os.ReadFile(listed); strings.Count(string(data), "listed") != 1 {
+		t.Errorf("encoders listed %d times, want once per Converter", strings.Count(string(data), "listed"))
+	}
+}
+
+func TestMislabeledInput(t *testing.T) {
+	fakeFFmpeg(t)
+	t.Setenv("FAKE_FFPROBE_FORMAT", "matroska,webm")
+	input := writeInput(t, "clip.mp4")
+
+	var warnings []string
+	c := &Converter{Warn: func(msg string) { warnings = append(warnings, msg) }}
+
+	// In place, the file keeps the container it really is.
+	opts := &Options{Input: input, InPlace: true}
+	if err := c.Run(opts, nil); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	want := strings.TrimSuffix(input, ".mp4") + ".mkv"
+	if opts.Format != "mkv" || opts.Output != want {
+		t.Errorf("in-place output %s (%s), want %s (mkv)", opts.Output, opts.Format, want)
+	}
+	if len(warnings) == 0 || warnings[0] != input+" is really mkv, not .mp4" {
+		t.Errorf("warnings = %q, want the real container named", warnings)
+	}
+}
+
+func TestInputExtension(t *testing.T) {
+	tests := []struct {
+		path, format, want string
+	}{
+		{"clip.mp4", "mov,mp4,m4a,3gp,3g2,mj2", "mp4"},
+		{"clip.mov", "mov,mp4,m4a,3gp,3g2,mj2", "mov"},
+		{"clip.mp4", "matroska,webm", "mkv"},
+		{"clip.webm", "matroska,webm", "webm"},
+		{"clip.mkv", "avi", "avi"},
+		// Containers fk-converter can't write keep the name's extension.
+		{"clip.mp4", "mpegts", "mp4"},
+		{"clip.mp4", "", "mp4"},
+	}
+	for _, tt := range tests {
+		if got := inputExtension(tt.path, &ProbeInfo{FormatName: tt.format}); got != tt.want {
+			t.Errorf("inputExtension(%s holding %q) = %q, want %q", tt.path, tt.format, got, tt.want)
+		}
+	}
+	if got := inputExtension("clip.mp4", nil); got != "mp4" {
+		t.Errorf("inputExtension without a probe = %q, want mp4", got)
+	}
+}
@@ -0,0 +1,107 @@
+package converter
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Crop is a crop rectangle as used by ffmpeg's crop filter.
+type Crop struct {
+	Width  int
+	Height int
+	X      int
+	Y      int
+}
+
+// Filter returns the crop as an ffmpeg filter, e.g. crop=1920:800:0:140.
+func (c Crop) Filter() string {
+	return fmt.Sprintf("crop=%d:%d:%d:%d", c.Width, c.Height, c.X, c.Y)
+}
+
+// CropDetection is the crop ffmpeg suggests for a video, with the size of
+// the black bars it removes.
+type CropDetection struct {
+	Crop Crop
+
+	SourceWidth  int
+	SourceHeight int
+
+	Top, Bottom, Left, Right int
+}
+
+const defaultCropDetectDuration = 60 * time.Second
+
+var cropDetectRegex = regexp.MustCompile(`crop=(\d+):(\d+):(\d+):(\d+)`)
+
+func DetectCrop(input string, duration time.Duration) (*CropDetection, error) {
+	return defaultConverter.DetectCrop(input, duration)
+}
+
+// DetectCrop runs ffmpeg's cropdetect filter over duration of the input
+// (default 60s), starting a tenth of the way in to skip black intros, and
+// returns the crop it suggested most often.
+func (c *Converter) DetectCrop(input string, duration time.Duration) (*CropDetection, error) {
+	if duration <= 0 {
+		duration = defaultCropDetectDuration
+	}
+
+	info, err := c.Probe(input)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read input %s, it may be corrupt or not a media file: %w", input, err)
+	}
+	v := info.VideoStream()
+	if v == nil {
+		return nil, fmt.Errorf("input has no video: %s", input)
+	}
+
+	cmd := exec.Command(c.ffmpeg(),
+		"-hide_banner",
+		"-ss", formatSeconds(info.Duration/10),
+		"-i", input,
+		"-t", formatSeconds(duration),
+		"-map", "0:v:0",
+		"-vf", "cropdetect",
+		"-an",
+		"-f", "null", "-",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("crop detection failed: %w\n%s", err, lastLines(string(out), 5))
+	}
+
+	crop, ok := parseCropDetect(string(out))
+	if !ok {
+		return nil, fmt.Errorf("crop detection found no frames in %s", input)
+	}
+	return &CropDetection{
+		Crop:         crop,
+		SourceWidth:  v.Width,
+		SourceHeight: v.Height,
+		Top:          crop.Y,
+		Bottom:       max(v.Height-crop.Y-crop.Height, 0),
+		Left:         crop.X,
+		Right:        max(v.Width-crop.X-crop.Width, 0),
+	}, nil
+}
+
+// parseCropDetect returns the crop cropdetect reported most often. Its
+// first reports are unreliable while it adapts, so ties go to the latest.
+func parseCropDetect(log string) (Crop, bool) {
+	counts := map[Crop]int{}
+	var best Crop
+	for _, m := range cropDetectRegex.FindAllStringSubmatch(log, -1) {
+		var crop Crop
+		crop.Width, _ = strconv.Atoi(m[1])
+		crop.Height, _ = strconv.Atoi(m[2])
+		crop.X, _ = strconv.Atoi(m[3])
+		crop.Y, _ = strconv.Atoi(m[4])
+		counts[crop]++
+		if counts[crop] >= counts[best] {
+			best = crop
+		}
+	}
+	return best, len(counts) > 0
+}
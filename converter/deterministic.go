@@ -0,0 +1,19 @@
+package converter
+
+// nondeterministicEncoders produce slightly different output from run to
+// run when multithreaded.
+var nondeterministicEncoders = map[string]bool{
+	"libx265":    true,
+	"libvpx-vp9": true,
+}
+
+// deterministicEncoderArgs keeps the audio encoder from writing version
+// strings into the stream (videoFlagsArgs does the same for the video) and
+// forces single-threaded encoding where threading changes the output.
+func deterministicEncoderArgs(opts *Options, codec string) []string {
+	args := []string{"-flags:a", "+bitexact"}
+	if nondeterministicEncoders[codec] && opts.Threads == 0 {
+		args = append(args, "-threads", "1")
+	}
+	return args
+}
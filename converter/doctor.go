@@ -0,0 +1,86 @@
+package converter
+
+import (
+	"fmt"
+	"maps"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// Check is the outcome of one environment check run by Doctor.
+type Check struct {
+	Name   string
+	OK     bool
+	Detail string
+}
+
+func Doctor() []Check {
+	return defaultConverter.Doctor()
+}
+
+// Doctor checks that ffmpeg and ffprobe can be run and that ffmpeg has
+// every encoder fk-converter may use. Hardware decoders are listed for
+// information and never fail.
+func (c *Converter) Doctor() []Check {
+	var checks []Check
+
+	ffmpegPath, err := exec.LookPath(c.ffmpeg())
+	if err != nil {
+		return append(checks, Check{Name: "ffmpeg", Detail: fmt.Sprintf("%s not found in PATH", c.ffmpeg())})
+	}
+	ffmpeg := Check{Name: "ffmpeg", OK: true, Detail: ffmpegPath}
+	if version, err := c.FFmpegVersion(); err == nil {
+		ffmpeg.Detail = version + " (" + ffmpegPath + ")"
+	}
+	checks = append(checks, ffmpeg)
+
+	if path, err := exec.LookPath(c.ffprobe()); err != nil {
+		checks = append(checks, Check{Name: "ffprobe", Detail: fmt.Sprintf("%s not found in PATH", c.ffprobe())})
+	} else {
+		checks = append(checks, Check{Name: "ffprobe", OK: true, Detail: path})
+	}
+
+	encoders, err := c.Encoders()
+	if err != nil {
+		return append(checks, Check{Name: "encoders", Detail: err.Error()})
+	}
+	for _, e := range doctorEncoders() {
+		checks = append(checks, Check{
+			Name:   "encoder " + e.encoder,
+			OK:     slices.Contains(encoders, e.encoder),
+			Detail: e.usedFor,
+		})
+	}
+
+	hwaccels := Check{Name: "hardware decoding", OK: true, Detail: "none"}
+	if apis, err := c.HWAccels(); err != nil {
+		hwaccels.Detail = err.Error()
+	} else if len(apis) > 0 {
+		hwaccels.Detail = strings.Join(apis, ", ")
+	}
+	return append(checks, hwaccels)
+}
+
+type doctorEncoder struct {
+	encoder string
+	usedFor string
+}
+
+// doctorEncoders lists the video encoders of codecMap, the audio encoders
+// of the output formats, those of --extract-audio and the webp command's,
+// in a stable order.
+func doctorEncoders() []doctorEncoder {
+	var list []doctorEncoder
+	for _, codec := range slices.Sorted(maps.Keys(codecMap)) {
+		list = append(list, doctorEncoder{codecMap[codec], "--codec " + codec})
+	}
+	list = append(list,
+		doctorEncoder{"aac", "audio in mp4, mkv, mov, avi"},
+		doctorEncoder{"libopus", "audio in webm"},
+	)
+	for _, ext := range []string{"mp3", "ogg", "flac", "wav"} {
+		list = append(list, doctorEncoder{audioFormats[ext].codec, "--extract-audio ." + ext})
+	}
+	return append(list, doctorEncoder{webpEncoder, "webp"})
+}
@@ -0,0 +1,102 @@
+package converter
+
+import (
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// codecFallbacks is the codec used instead of each codec when its encoder
+// is missing and Fallback is set.
+var codecFallbacks = map[string]string{
+	"h265": "h264",
+	"vp9":  "h264",
+}
+
+func Encoders() ([]string, error) {
+	return defaultConverter.Encoders()
+}
+
+// encoderCache holds the encoder list, queried once per Converter.
+type encoderCache struct {
+	once     sync.Once
+	encoders []string
+	err      error
+}
+
+// Encoders lists the encoders compiled into the ffmpeg build.
+func (c *Converter) Encoders() ([]string, error) {
+	c.encoders.once.Do(func() {
+		c.encoders.encoders, c.encoders.err = c.queryEncoders()
+	})
+	return slices.Clone(c.encoders.encoders), c.encoders.err
+}
+
+func (c *Converter) queryEncoders() ([]string, error) {
+	out, err := exec.Command(c.ffmpeg(), "-hide_banner", "-encoders").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ffmpeg encoders: %w", err)
+	}
+
+	// Encoder lines look like " V....D libx264   H.264 ...", below a legend
+	// of " V..... = Video" lines.
+	var encoders []string
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || len(fields[0]) != 6 || fields[1] == "=" {
+			continue
+		}
+		encoders = append(encoders, fields[1])
+	}
+	return encoders, nil
+}
+
+// validateEncoder checks that ffmpeg has the selected video encoder. When
+// the encoders can't be listed, ffmpeg reports a missing one itself. It
+// runs once the input is probed, since a remux needs no encoder.
+func (c *Converter) validateEncoder(opts *Options) error {
+	encoders, err := c.Encoders()
+	if err != nil {
+		return nil
+	}
+	codec := videoCodec(opts)
+	if slices.Contains(encoders, codec) {
+		return nil
+	}
+	if fallback, ok := c.encoderFallback(opts, encoders); ok {
+		return fmt.Errorf("encoder %s is not available in this ffmpeg build (use --allow-fallback to encode with %s instead)", codec, fallback)
+	}
+	return fmt.Errorf("encoder %s is not available in this ffmpeg build", codec)
+}
+
+// encoderFallback returns the codec to use instead of opts.Codec, if it has
+// a fallback the output container holds and ffmpeg has.
+func (c *Converter) encoderFallback(opts *Options, encoders []string) (string, bool) {
+	fallback, ok := codecFallbacks[opts.Codec]
+	if !ok {
+		return "", false
+	}
+	encoder := codecMap[fallback]
+	if !slices.Contains(containerCodecs[opts.Format], encoder) || !slices.Contains(encoders, encoder) {
+		return "", false
+	}
+	return fallback, true
+}
+
+// applyEncoderFallback switches to the fallback codec, with a warning, when
+// Fallback is set and the requested encoder is missing.
+func (c *Converter) applyEncoderFallback(opts *Options) {
+	if !opts.Fallback || opts.Codec == "" {
+		return
+	}
+	encoders, err := c.Encoders()
+	if err != nil || slices.Contains(encoders, videoCodec(opts)) {
+		return
+	}
+	if fallback, ok := c.encoderFallback(opts, encoders); ok {
+		c.warn("%s is not available in this ffmpeg build; encoding with %s instead", videoCodec(opts), codecMap[fallback])
+		opts.Codec = fallback
+	}
+}
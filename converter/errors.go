@@ -0,0 +1,86 @@
+package converter
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrFFmpegNotFound is returned when the ffmpeg binary can't be found
+	// or started.
+	ErrFFmpegNotFound = errors.New("ffmpeg not found")
+
+	// ErrFFmpegTooOld is returned when a feature needs a newer ffmpeg than
+	// the one installed.
+	ErrFFmpegTooOld = errors.New("ffmpeg too old")
+
+	// ErrUnsupportedFormat is returned for an output format fk-converter
+	// can't write.
+	ErrUnsupportedFormat = errors.New("unsupported format")
+
+	// ErrInvalidOptions matches every error returned by ValidateOptions,
+	// ValidateFramesOptions and ValidateSplitOptions.
+	ErrInvalidOptions = errors.New("invalid options")
+)
+
+// invalidOptionsError marks a validation error as ErrInvalidOptions while
+// keeping its message.
+type invalidOptionsError struct {
+	err error
+}
+
+func (e *invalidOptionsError) Error() string { return e.err.Error() }
+
+func (e *invalidOptionsError) Unwrap() error { return e.err }
+
+func (e *invalidOptionsError) Is(target error) bool { return target == ErrInvalidOptions }
+
+func invalidOptions(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &invalidOptionsError{err}
+}
+
+// ConversionError is returned when ffmpeg exits with an error. It carries
+// the exit code (-1 if ffmpeg was killed) and the last lines ffmpeg wrote
+// to stderr, which usually say what went wrong.
+type ConversionError struct {
+	ExitCode int
+	Stderr   string
+	Err      error
+}
+
+func (e *ConversionError) Error() string {
+	if e.Stderr == "" {
+		return e.Err.Error()
+	}
+	return e.Err.Error() + "\n" + e.Stderr
+}
+
+func (e *ConversionError) Unwrap() error { return e.Err }
+
+// stderrTailLines is how much of ffmpeg's stderr a ConversionError keeps.
+const stderrTailLines = 5
+
+// stderrTail keeps the last lines written to it.
+type stderrTail struct {
+	lines []string
+}
+
+func (t *stderrTail) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		t.lines = append(t.lines, line)
+		if len(t.lines) > stderrTailLines {
+			t.lines = t.lines[1:]
+		}
+	}
+	return len(p), nil
+}
+
+func (t *stderrTail) String() string {
+	return strings.Join(t.lines, "\n")
+}
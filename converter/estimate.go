@@ -0,0 +1,113 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Estimate projects a full conversion from an encode of a sample of it.
+type Estimate struct {
+	// Duration is the length of the full output.
+	Duration time.Duration
+
+	// SampleDuration is the length of the encoded sample, SampleTime how
+	// long encoding it took and SampleSize its size in bytes.
+	SampleDuration time.Duration
+	SampleTime     time.Duration
+	SampleSize     int64
+
+	// Speed is the encoding speed as a multiple of realtime.
+	Speed float64
+
+	// EncodeTime and Size are the projected time and output size of the
+	// full conversion, and Bitrate its overall bitrate in bits per second.
+	EncodeTime time.Duration
+	Size       int64
+	Bitrate    int64
+}
+
+func EstimateConversion(opts *Options, sample time.Duration, onProgress ProgressFunc) (*Estimate, error) {
+	return defaultConverter.Estimate(context.Background(), opts, sample, onProgress)
+}
+
+// Estimate encodes sample of the middle of the conversion opts describes to
+// a temporary file and extrapolates the full encode time and output size
+// from it. Unlike a guess from the settings alone, it measures the actual
+// encoder on the actual content, but content that changes a lot over the
+// file still makes the projection approximate.
+func (c *Converter) Estimate(ctx context.Context, opts *Options, sample time.Duration, onProgress ProgressFunc) (*Estimate, error) {
+	if sample <= 0 {
+		return nil, fmt.Errorf("invalid sample duration: %s (must be positive)", sample)
+	}
+	if err := c.Prepare(opts); err != nil {
+		return nil, err
+	}
+	if opts.SkipProbe {
+		return nil, fmt.Errorf("--skip-probe: the input must be probed to estimate its conversion")
+	}
+	src, err := c.probeInput(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	total := conversionTotal(opts, src)
+	if opts.Audio != "" {
+		total = c.stillImageTotal(opts)
+	}
+	if total.duration <= 0 {
+		return nil, fmt.Errorf("cannot estimate the conversion of %s: its length is unknown", opts.Input)
+	}
+	sample = min(sample, total.duration)
+
+	temps := &cleanup{}
+	defer temps.removeAll()
+
+	// The name already ends in _sample, so ResolveOutput keeps it.
+	tmp, err := temps.createTemp("", "fk-converter-estimate-*_sample."+opts.Format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary output: %w", err)
+	}
+	tmp.Close()
+
+	sampleOpts := *opts
+	sampleOpts.Output = tmp.Name()
+	sampleOpts.OutputDir = ""
+	sampleOpts.InPlace = false
+	sampleOpts.Renditions = nil
+	sampleOpts.MaxSize = 0
+	sampleOpts.MinQualityCRF = 0
+	sampleOpts.ExtractAudio = ""
+	sampleOpts.Verify = false
+	sampleOpts.VerifyFull = false
+	sampleOpts.SampleDuration = sample
+	if opts.Loop == 0 && opts.Audio == "" {
+		// Openings are often titles or black, which encode unusually fast
+		// and small.
+		sampleOpts.Start = opts.Start + (total.duration-sample)/2
+	}
+
+	start := time.Now()
+	if err := c.RunContext(ctx, &sampleOpts, onProgress); err != nil {
+		return nil, fmt.Errorf("sample encode failed: %w", err)
+	}
+	elapsed := time.Since(start)
+
+	info, err := os.Stat(tmp.Name())
+	if err != nil {
+		return nil, fmt.Errorf("sample encode failed: %w", err)
+	}
+
+	scale := total.duration.Seconds() / sample.Seconds()
+	return &Estimate{
+		Duration:       total.duration,
+		SampleDuration: sample,
+		SampleTime:     elapsed,
+		SampleSize:     info.Size(),
+		Speed:          sample.Seconds() / elapsed.Seconds(),
+		EncodeTime:     time.Duration(float64(elapsed) * scale),
+		Size:           int64(float64(info.Size()) * scale),
+		Bitrate:        int64(float64(info.Size()) * 8 / sample.Seconds()),
+	}, nil
+}
@@ -0,0 +1,87 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+)
+
+// maxCRF is the highest (lowest quality) CRF of each encoder.
+var maxCRF = map[string]int{
+	"libx264":    51,
+	"libx265":    51,
+	"libvpx-vp9": 63,
+}
+
+func validateMaxSize(opts *Options) error {
+	if opts.MaxSize < 0 {
+		return fmt.Errorf("invalid max size: %d (must be positive)", opts.MaxSize)
+	}
+	if (opts.MaxSize > 0) != (opts.MinQualityCRF > 0) {
+		return fmt.Errorf("--max-size and --min-quality-fallback must be used together")
+	}
+	if opts.MaxSize == 0 {
+		return nil
+	}
+
+	if opts.Quality == QualityLossless || opts.RateControl == RateControlBitrate || opts.VideoBitrate != "" {
+		return fmt.Errorf("--max-size needs CRF encoding and cannot be combined with lossless quality, --rate-control bitrate or a video bitrate")
+	}
+
+	codec := videoCodec(opts)
+	limit, ok := maxCRF[codec]
+	if !ok {
+		return fmt.Errorf("--max-size is not supported with %s", codec)
+	}
+	if start := crfMap[opts.Quality]; opts.MinQualityCRF < start || opts.MinQualityCRF > limit {
+		return fmt.Errorf("invalid --min-quality-fallback: %d (must be between %d, the CRF of quality %s, and %d)", opts.MinQualityCRF, start, opts.Quality, limit)
+	}
+	if opts.InPlace {
+		return fmt.Errorf("--max-size cannot be combined with --in-place")
+	}
+	return nil
+}
+
+// encodeToFit encodes opts again at ever lower quality until the output is
+// no larger than MaxSize, stopping at MinQualityCRF. Only the encode is
+// repeated: RunContext runs the pre-passes before and finishes the output
+// once after. The output of the last attempt is kept even if it doesn't
+// fit, with a warning.
+func (c *Converter) encodeToFit(ctx context.Context, opts *Options, src *ProbeInfo, onProgress ProgressFunc) error {
+	crf := min(videoCRF(opts, src), opts.MinQualityCRF)
+
+	for attempt := 1; ; attempt++ {
+		opts.crf = crf
+		if err := c.encode(ctx, opts, src, onProgress); err != nil {
+			return err
+		}
+		info, err := os.Stat(opts.Output)
+		if err != nil {
+			return fmt.Errorf("failed to measure output: %w", err)
+		}
+		if opts.OnFitAttempt != nil {
+			opts.OnFitAttempt(attempt, crf, info.Size())
+		}
+		if info.Size() <= opts.MaxSize {
+			return nil
+		}
+		if crf >= opts.MinQualityCRF {
+			c.warn("%s is %.1f MB, over the %.1f MB budget even at the CRF %d floor (attempt %d)",
+				opts.Output, megabytes(info.Size()), megabytes(opts.MaxSize), crf, attempt)
+			return nil
+		}
+		crf = min(nextFitCRF(crf, info.Size(), opts.MaxSize), opts.MinQualityCRF)
+	}
+}
+
+// nextFitCRF estimates the CRF that shrinks an encode of size to budget:
+// raising it by 6 about halves the size with x264, x265 and vp9.
+func nextFitCRF(crf int, size, budget int64) int {
+	step := int(math.Ceil(6 * math.Log2(float64(size)/float64(budget))))
+	return crf + max(step, 1)
+}
+
+func megabytes(size int64) float64 {
+	return float64(size) / 1024 / 1024
+}
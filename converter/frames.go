@@ -0,0 +1,185 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FramesOptions describes an image sequence export. Output is a pattern
+// with one zero-padded frame number placeholder, e.g. frames/shot_%04d.png.
+type FramesOptions struct {
+	Input  string
+	Output string
+
+	// StartNumber is the number of the first image written.
+	StartNumber int
+
+	// KeyframesOnly exports only the video's keyframes (I-frames), which
+	// is much faster and gives a quick overview of a long video.
+	KeyframesOnly bool
+
+	// SampleFPS exports this many frames per second of video, evenly
+	// spaced whatever the source frame rate, e.g. for ML datasets.
+	SampleFPS float64
+
+	// JPEGQuality sets the JPEG quality scale (-q:v) from 2 (best) to 31
+	// (smallest). Zero uses ffmpeg's default.
+	JPEGQuality int
+}
+
+var framePatternRegex = regexp.MustCompile(`%0(\d+)d`)
+
+var imageFormats = map[string]bool{
+	"png":  true,
+	"jpg":  true,
+	"jpeg": true,
+	"bmp":  true,
+	"tiff": true,
+	"webp": true,
+}
+
+func ExportFrames(opts *FramesOptions, onProgress ProgressFunc) ([]string, error) {
+	return defaultConverter.ExportFrames(opts, onProgress)
+}
+
+// ResolveFramesOutput defaults the output pattern to name_%04d.png next to
+// the input, or name_%06d.jpg when sampling at SampleFPS.
+func ResolveFramesOutput(opts *FramesOptions) {
+	if opts.Output == "" && opts.SampleFPS > 0 {
+		opts.Output = trimExtension(opts.Input) + "_%06d.jpg"
+	} else if opts.Output == "" {
+		opts.Output = trimExtension(opts.Input) + "_%04d.png"
+	}
+}
+
+// ValidateFramesOptions checks opts before running. Every error it returns
+// matches ErrInvalidOptions.
+func ValidateFramesOptions(opts *FramesOptions) error {
+	return invalidOptions(validateFramesOptions(opts))
+}
+
+func validateFramesOptions(opts *FramesOptions) error {
+	if _, err := os.Stat(opts.Input); os.IsNotExist(err) {
+		return fmt.Errorf("input file does not exist: %s", opts.Input)
+	}
+	if ext := getExtension(opts.Output); !imageFormats[ext] {
+		return fmt.Errorf("unsupported image format: %s (supported: png, jpg, jpeg, bmp, tiff, webp)", ext)
+	}
+	if _, err := frameNumberWidth(opts.Output); err != nil {
+		return err
+	}
+	existing, err := existingFrames(opts.Output)
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return fmt.Errorf("%s already holds %d frame(s) matching %s; remove them or choose another output", filepath.Dir(opts.Output), len(existing), filepath.Base(opts.Output))
+	}
+	if opts.StartNumber < 0 {
+		return fmt.Errorf("invalid start number: %d (must be 0 or greater)", opts.StartNumber)
+	}
+	if opts.SampleFPS < 0 || opts.SampleFPS > 240 {
+		return fmt.Errorf("invalid sample fps: %g (must be between 0 and 240, e.g. 1 or 0.5)", opts.SampleFPS)
+	}
+	if opts.SampleFPS > 0 && opts.KeyframesOnly {
+		return fmt.Errorf("--sample-fps cannot be combined with --keyframes-only")
+	}
+	if opts.JPEGQuality != 0 {
+		if ext := getExtension(opts.Output); ext != "jpg" && ext != "jpeg" {
+			return fmt.Errorf("--jpeg-quality requires jpg output, not %s", ext)
+		}
+		if opts.JPEGQuality < 2 || opts.JPEGQuality > 31 {
+			return fmt.Errorf("invalid jpeg quality: %d (must be 2-31, lower is better)", opts.JPEGQuality)
+		}
+	}
+	return nil
+}
+
+// frameNumberWidth returns the zero-padding width of the pattern's single
+// %0Nd placeholder.
+func frameNumberWidth(pattern string) (int, error) {
+	name := filepath.Base(pattern)
+	matches := framePatternRegex.FindAllStringSubmatch(name, -1)
+	if len(matches) != 1 || strings.Count(name, "%") != 1 {
+		return 0, fmt.Errorf("invalid output pattern: %s (needs exactly one frame number placeholder like %%04d)", pattern)
+	}
+	width, _ := strconv.Atoi(matches[0][1])
+	if width < 1 || width > 9 {
+		return 0, fmt.Errorf("invalid frame number width in %s: %d (must be 1-9)", pattern, width)
+	}
+	return width, nil
+}
+
+// existingFrames returns the files in the pattern's directory that the
+// pattern could produce, whatever their number.
+func existingFrames(pattern string) ([]string, error) {
+	base := filepath.Base(pattern)
+	loc := framePatternRegex.FindStringIndex(base)
+	match := regexp.MustCompile("^" + regexp.QuoteMeta(base[:loc[0]]) + `\d+` + regexp.QuoteMeta(base[loc[1]:]) + "$")
+
+	entries, err := os.ReadDir(filepath.Dir(pattern))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read output directory: %w", err)
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && match.MatchString(e.Name()) {
+			files = append(files, filepath.Join(filepath.Dir(pattern), e.Name()))
+		}
+	}
+	return files, nil
+}
+
+// ExportFrames writes the input's frames as numbered images and returns
+// their paths. It refuses to write into a directory that already holds
+// frames matching the pattern, so the result is exactly this run's images.
+func (c *Converter) ExportFrames(opts *FramesOptions, onProgress ProgressFunc) ([]string, error) {
+	ResolveFramesOutput(opts)
+	if err := ValidateFramesOptions(opts); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(opts.Output), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	total, err := c.probeDuration(opts.Input)
+	if err != nil {
+		total = 0
+	}
+
+	args := []string{"-i", opts.Input, "-y", "-progress", "pipe:2", "-nostats"}
+	if opts.KeyframesOnly {
+		args = append(args, "-vf", "select='eq(pict_type,I)'", "-vsync", "0")
+	}
+	if opts.SampleFPS > 0 {
+		args = append(args, "-vf", "fps="+strconv.FormatFloat(opts.SampleFPS, 'f', -1, 64))
+	}
+	if opts.JPEGQuality > 0 {
+		args = append(args, "-q:v", strconv.Itoa(opts.JPEGQuality))
+	}
+	args = append(args, "-start_number", strconv.Itoa(opts.StartNumber), opts.Output)
+
+	if err := c.runFFmpeg(context.Background(), args, progressTotal{duration: total}, progressReporter(onProgress, nil)); err != nil {
+		return nil, fmt.Errorf("ffmpeg frame export failed: %w", err)
+	}
+
+	// ffmpeg numbers the images consecutively from StartNumber.
+	var files []string
+	for n := opts.StartNumber; ; n++ {
+		name := filepath.Join(filepath.Dir(opts.Output), fmt.Sprintf(filepath.Base(opts.Output), n))
+		if _, err := os.Stat(name); err != nil {
+			break
+		}
+		files = append(files, name)
+	}
+	return files, nil
+}
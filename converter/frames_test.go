@@ -0,0 +1,44 @@
+package converter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestExportFrames(t *testing.T) {
+	fakeFFmpeg(t)
+	input := writeInput(t, "take[1].mov")
+	dir := t.TempDir()
+
+	// take1_0001.png matches the pattern as a glob but not as a name the
+	// pattern produces.
+	unrelated := filepath.Join(dir, "take1_0001.png")
+	if err := os.WriteFile(unrelated, []byte("other"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(dir, "take[1]_%04d.png")
+	files, err := ExportFrames(&FramesOptions{Input: input, Output: output, StartNumber: 1001}, nil)
+	if err != nil {
+		t.Fatalf("ExportFrames: %v", err)
+	}
+	want := []string{
+		filepath.Join(dir, "take[1]_1001.png"),
+		filepath.Join(dir, "take[1]_1002.png"),
+		filepath.Join(dir, "take[1]_1003.png"),
+	}
+	if !slices.Equal(files, want) {
+		t.Errorf("files = %q, want %q", files, want)
+	}
+
+	_, err = ExportFrames(&FramesOptions{Input: input, Output: output}, nil)
+	if !errors.Is(err, ErrInvalidOptions) {
+		t.Errorf("second export into %s: err = %v, want ErrInvalidOptions", dir, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "take[1]_0000.png")); !os.IsNotExist(err) {
+		t.Errorf("second export wrote frames: %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package converter
+
+import (
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+var hwDecodeAPIs = map[string]bool{
+	"cuda":         true,
+	"videotoolbox": true,
+	"qsv":          true,
+	"vaapi":        true,
+}
+
+var hwEncoderSuffixes = []string{"_nvenc", "_videotoolbox", "_qsv", "_vaapi"}
+
+func HWAccels() ([]string, error) {
+	return defaultConverter.HWAccels()
+}
+
+// HWAccels lists the hardware decode APIs compiled into the ffmpeg build.
+func (c *Converter) HWAccels() ([]string, error) {
+	out, err := exec.Command(c.ffmpeg(), "-hide_banner", "-hwaccels").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ffmpeg hwaccels: %w", err)
+	}
+
+	var apis []string
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasSuffix(line, ":") {
+			continue
+		}
+		apis = append(apis, line)
+	}
+	return apis, nil
+}
+
+func (c *Converter) validateHWDecode(api string) error {
+	if !hwDecodeAPIs[api] {
+		return fmt.Errorf("unsupported hardware decode API: %s (supported: cuda, videotoolbox, qsv, vaapi)", api)
+	}
+
+	available, err := c.HWAccels()
+	if err != nil {
+		return err
+	}
+	if slices.Contains(available, api) {
+		return nil
+	}
+	return fmt.Errorf("hardware decode API %s is not available in this ffmpeg build (available: %s)", api, strings.Join(available, ", "))
+}
+
+func isHardwareEncoder(codec string) bool {
+	for _, suffix := range hwEncoderSuffixes {
+		if strings.HasSuffix(codec, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// hwDecodeArgs returns the input options for GPU decoding. Frames stay in
+// GPU memory only when a hardware encoder consumes them; software encoders
+// need them downloaded to system memory, which ffmpeg does when no output
+// format is forced.
+func hwDecodeArgs(opts *Options) []string {
+	if opts.HWDecode == "" {
+		return nil
+	}
+	args := []string{"-hwaccel", opts.HWDecode}
+	if isHardwareEncoder(videoCodec(opts)) {
+		args = append(args, "-hwaccel_output_format", opts.HWDecode)
+	}
+	return args
+}
@@ -0,0 +1,110 @@
+package converter
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+var deinterlaceModes = map[string]bool{
+	"yadif": true,
+	"bwdif": true,
+}
+
+var idetRegex = regexp.MustCompile(`Multi frame detection: TFF:\s*(\d+)\s+BFF:\s*(\d+)\s+Progressive:\s*(\d+)`)
+
+// DetectInterlace runs ffmpeg's idet filter over the first frames of the
+// input and reports whether most of them are interlaced.
+func DetectInterlace(input string) (bool, error) {
+	return defaultConverter.DetectInterlace(input)
+}
+
+func (c *Converter) DetectInterlace(input string) (bool, error) {
+	cmd := exec.Command(c.ffmpeg(),
+		"-hide_banner",
+		"-i", input,
+		"-map", "0:v:0",
+		"-vf", "idet",
+		"-frames:v", "500",
+		"-an",
+		"-f", "null", "-",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return false, fmt.Errorf("interlace detection failed: %w", err)
+	}
+
+	matches := idetRegex.FindStringSubmatch(string(out))
+	if len(matches) != 4 {
+		return false, fmt.Errorf("interlace detection produced no result")
+	}
+
+	tff, _ := strconv.Atoi(matches[1])
+	bff, _ := strconv.Atoi(matches[2])
+	progressive, _ := strconv.Atoi(matches[3])
+	return tff+bff > progressive, nil
+}
+
+func deinterlaceFilter(opts *Options) string {
+	mode := opts.DeinterlaceMode
+	if mode == "" {
+		mode = "yadif"
+	}
+	return mode
+}
+
+// interlaceFieldOrders maps the field orders of Interlace to ffmpeg's
+// -field_order values.
+var interlaceFieldOrders = map[string]string{
+	"tff": "tt",
+	"bff": "bb",
+}
+
+// interlaceEncoders are the encoders that can code interlaced video.
+var interlaceEncoders = []string{"libx264"}
+
+func validateInterlace(opts *Options) error {
+	if _, ok := interlaceFieldOrders[opts.Interlace]; !ok {
+		return fmt.Errorf("unsupported field order: %s (supported: tff, bff)", opts.Interlace)
+	}
+	if opts.Deinterlace || opts.DetectInterlace {
+		return fmt.Errorf("--interlace cannot be combined with --deinterlace or --detect-interlace")
+	}
+	if codec := videoCodec(opts); !slices.Contains(interlaceEncoders, codec) {
+		return fmt.Errorf("--interlace is only supported with h264 (%s cannot code interlaced video)", codec)
+	}
+	return nil
+}
+
+// interlaceFilter weaves pairs of progressive frames into interlaced
+// frames, halving the frame rate: 50p becomes 25i (50 fields a second).
+func interlaceFilter(opts *Options) string {
+	return "interlace=scan=" + opts.Interlace
+}
+
+// interlaceArgs tags the field order. The encoder is told to code the
+// frames as interlaced by videoFlagsArgs.
+func interlaceArgs(opts *Options) []string {
+	if opts.Interlace == "" {
+		return nil
+	}
+	return []string{"-field_order", interlaceFieldOrders[opts.Interlace]}
+}
+
+// videoFlagsArgs returns the video encoder's -flags. ffmpeg keeps only the
+// last -flags given for a stream, so every feature's flags share one value.
+func videoFlagsArgs(opts *Options) []string {
+	var flags string
+	if opts.Interlace != "" {
+		flags += "+ilme+ildct"
+	}
+	if opts.Deterministic {
+		flags += "+bitexact"
+	}
+	if flags == "" {
+		return nil
+	}
+	return []string{"-flags:v", flags}
+}
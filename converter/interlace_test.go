@@ -0,0 +1,30 @@
+package converter
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestInterlaceDeterministicFlags(t *testing.T) {
+	opts := &Options{Format: "mp4", Codec: "h264", Quality: QualityMedium, Interlace: "tff", Deterministic: true}
+	args := buildOutputArgs(opts, nil)
+
+	var flags []string
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "-flags") && arg != "-flags:a" {
+			flags = append(flags, args[i+1])
+		}
+	}
+	if len(flags) != 1 {
+		t.Fatalf("video flags given %d times in %q, want once (ffmpeg keeps only the last)", len(flags), args)
+	}
+	for _, flag := range []string{"+ilme", "+ildct", "+bitexact"} {
+		if !strings.Contains(flags[0], flag) {
+			t.Errorf("video flags %q lack %s", flags[0], flag)
+		}
+	}
+	if i := slices.Index(args, "-field_order"); i < 0 || args[i+1] != "tt" {
+		t.Errorf("args %q don't tag the field order tt", args)
+	}
+}
@@ -0,0 +1,142 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadJobs reads a job spec: a JSON or YAML (.yaml, .yml) file holding an
+// object of Options fields, or a list of them to convert one after
+// another. Keys match field names ignoring case, dashes and underscores
+// (customVideoFilter, custom_video_filter and custom-video-filter are the
+// same), durations are strings like "1m30s" and fileMode is octal, e.g.
+// "0644". renditions is a list of {resolution, bitrate, output} objects or a
+// spec like "1080p:6M,720p:3M" (see ParseRenditions). Unknown keys are
+// errors, so typos don't go unnoticed.
+func LoadJobs(path string) ([]*Options, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job spec: %w", err)
+	}
+
+	var doc any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &doc)
+	default:
+		err = json.Unmarshal(data, &doc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse job spec %s: %w", path, err)
+	}
+
+	specs, ok := doc.([]any)
+	if !ok {
+		specs = []any{doc}
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("job spec %s has no jobs", path)
+	}
+
+	jobs := make([]*Options, len(specs))
+	for i, spec := range specs {
+		fields, ok := spec.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("job spec %s: job %d is not an object of options", path, i+1)
+		}
+		opts, err := jobOptions(fields)
+		if err != nil {
+			return nil, fmt.Errorf("job spec %s: job %d: %w", path, i+1, err)
+		}
+		jobs[i] = opts
+	}
+	return jobs, nil
+}
+
+var (
+	durationType   = reflect.TypeFor[time.Duration]()
+	fileModeType   = reflect.TypeFor[os.FileMode]()
+	renditionsType = reflect.TypeFor[[]Rendition]()
+)
+
+// jobOptions sets the Options fields named by the keys of spec.
+func jobOptions(spec map[string]any) (*Options, error) {
+	opts := &Options{}
+	v := reflect.ValueOf(opts).Elem()
+
+	fields := map[string]int{}
+	for i := range v.NumField() {
+		if f := v.Type().Field(i); f.IsExported() && f.Type.Kind() != reflect.Func {
+			fields[jobKey(f.Name)] = i
+		}
+	}
+
+	for key, value := range spec {
+		i, ok := fields[jobKey(key)]
+		if !ok {
+			return nil, fmt.Errorf("unknown option: %s", key)
+		}
+		if err := setJobField(v.Field(i), value); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", key, err)
+		}
+	}
+	if opts.Input == "" {
+		return nil, fmt.Errorf("missing input")
+	}
+	return opts, nil
+}
+
+func jobKey(name string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(name))
+}
+
+func setJobField(field reflect.Value, value any) error {
+	switch field.Type() {
+	case durationType:
+		switch value := value.(type) {
+		case string:
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return err
+			}
+			field.SetInt(int64(d))
+			return nil
+		case int, float64:
+			return fmt.Errorf("%v has no unit (write e.g. \"%vs\")", value, value)
+		}
+	case fileModeType:
+		if s, ok := value.(string); ok {
+			mode, err := strconv.ParseUint(s, 8, 32)
+			if err != nil {
+				return fmt.Errorf("%s is not an octal mode like \"0644\"", s)
+			}
+			field.SetUint(mode)
+			return nil
+		}
+		return fmt.Errorf("%v must be a quoted octal mode like \"0644\"", value)
+	case renditionsType:
+		if s, ok := value.(string); ok {
+			renditions, err := ParseRenditions(s)
+			if err != nil {
+				return err
+			}
+			field.Set(reflect.ValueOf(renditions))
+			return nil
+		}
+	}
+
+	// Everything else takes its JSON form, which YAML values share.
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, field.Addr().Interface())
+}
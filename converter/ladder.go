@@ -0,0 +1,157 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+)
+
+type Rendition struct {
+	Resolution string
+	Bitrate    string
+	Output     string
+}
+
+var bitrateRegex = regexp.MustCompile(`^\d+(\.\d+)?[kKmM]?$`)
+
+// ParseRenditions parses a rendition spec such as "1080p:6M,720p:3M".
+func ParseRenditions(spec string) ([]Rendition, error) {
+	var renditions []Rendition
+	for _, part := range strings.Split(spec, ",") {
+		res, bitrate, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid rendition %q (expected resolution:bitrate, e.g. 720p:3M)", part)
+		}
+		r := Rendition{Resolution: res, Bitrate: bitrate}
+		if err := validateRendition(r); err != nil {
+			return nil, err
+		}
+		renditions = append(renditions, r)
+	}
+	return renditions, nil
+}
+
+func validateRendition(r Rendition) error {
+	if !isValidResolution(r.Resolution) {
+		return fmt.Errorf("invalid rendition resolution: %s (examples: 1080p, 720p, or 1280x720)", r.Resolution)
+	}
+	if !bitrateRegex.MatchString(r.Bitrate) {
+		return fmt.Errorf("invalid rendition bitrate: %s (examples: 6M, 1.5M, 800k)", r.Bitrate)
+	}
+	return nil
+}
+
+func validateRenditions(opts *Options) error {
+	for _, r := range opts.Renditions {
+		if err := validateRendition(r); err != nil {
+			return err
+		}
+	}
+	if opts.InPlace {
+		return fmt.Errorf("--in-place cannot be combined with renditions, which write several outputs")
+	}
+	return nil
+}
+
+func ConvertLadder(base *Options, renditions []Rendition, onProgress ProgressFunc) ([]Rendition, error) {
+	return defaultConverter.RunLadder(context.Background(), base, renditions, onProgress)
+}
+
+// RunLadder encodes every rendition in a single ffmpeg run, so the input is
+// decoded once. Renditions without an Output are named after the input with
+// their resolution as suffix, in OutputDir if set. Each output is finished
+// like a single conversion (verified, timestamped, chmodded); the audio is
+// extracted once. It returns the renditions with outputs filled in.
+func (c *Converter) RunLadder(ctx context.Context, base *Options, renditions []Rendition, onProgress ProgressFunc) ([]Rendition, error) {
+	if len(renditions) == 0 {
+		return nil, fmt.Errorf("no renditions given")
+	}
+	if base.InPlace {
+		return nil, invalidOptions(fmt.Errorf("--in-place cannot be used with ladder, which writes several outputs"))
+	}
+	if err := c.Prepare(base); err != nil {
+		return nil, err
+	}
+
+	for i := range renditions {
+		r := &renditions[i]
+		if r.Output == "" {
+			r.Output = trimExtension(base.Input) + "_" + r.Resolution + "." + base.Format
+			if base.OutputDir != "" {
+				r.Output = filepath.Join(base.OutputDir, filepath.Base(r.Output))
+			}
+		}
+		v := rendition(base, r, i)
+		if err := c.ValidateOptions(&v); err != nil {
+			return nil, fmt.Errorf("rendition %s: %w", r.Resolution, err)
+		}
+	}
+
+	if err := c.validateEncoder(base); err != nil {
+		return nil, invalidOptions(err)
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	temps := &cleanup{}
+	defer temps.removeAll()
+
+	// The pre-passes run once; every rendition shares their results.
+	prepared := *base
+	defer func() { *base = prepared }()
+
+	src, err := c.prepareRun(ctx, base, temps)
+	if err != nil {
+		return nil, err
+	}
+
+	stamp, err := sourceTimestamp(base, src)
+	if err != nil {
+		return nil, err
+	}
+
+	args := buildInputArgs(base)
+	for i := range renditions {
+		v := rendition(base, &renditions[i], i)
+		args = append(args, buildOutputArgs(&v, src)...)
+		args = append(args, extraArgs(&v)...)
+		args = append(args, v.Output)
+	}
+
+	if err := c.runFFmpegNiced(ctx, base.Nice, args, conversionTotal(base, src), progressReporter(onProgress, nil)); err != nil {
+		if ctx.Err() != nil {
+			for _, r := range renditions {
+				os.Remove(r.Output)
+			}
+			return nil, fmt.Errorf("conversion interrupted: %w", ctx.Err())
+		}
+		return nil, fmt.Errorf("ffmpeg conversion failed: %w", err)
+	}
+
+	for i := range renditions {
+		v := rendition(base, &renditions[i], i)
+		if err := c.finishRun(ctx, &v, src, stamp, v.Output); err != nil {
+			return nil, fmt.Errorf("rendition %s: %w", v.Resolution, err)
+		}
+	}
+	return renditions, nil
+}
+
+// rendition returns the options encoding the i-th rendition r: base at r's
+// size and bitrate. Only the first extracts the audio.
+func rendition(base *Options, r *Rendition, i int) Options {
+	v := *base
+	v.Resolution = r.Resolution
+	v.VideoBitrate = r.Bitrate
+	v.Output = r.Output
+	if i > 0 {
+		v.ExtractAudio = ""
+	}
+	return v
+}
@@ -0,0 +1,48 @@
+package converter
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// languageCodes are the ISO 639-2 codes accepted for stream language tags,
+// covering the languages media servers commonly list plus "und"
+// (undetermined). Both bibliographic and terminology variants are accepted.
+var languageCodes = map[string]bool{
+	"ara": true, "ben": true, "bul": true, "cat": true, "ces": true,
+	"cze": true, "chi": true, "zho": true, "dan": true, "deu": true,
+	"ger": true, "ell": true, "gre": true, "eng": true, "est": true,
+	"fas": true, "per": true, "fin": true, "fil": true, "fra": true,
+	"fre": true, "heb": true, "hin": true, "hrv": true, "hun": true,
+	"ind": true, "isl": true, "ice": true, "ita": true, "jpn": true,
+	"kor": true, "lav": true, "lit": true, "msa": true, "may": true,
+	"nld": true, "dut": true, "nor": true, "nob": true, "nno": true,
+	"pol": true, "por": true, "ron": true, "rum": true, "rus": true,
+	"slk": true, "slo": true, "slv": true, "spa": true, "srp": true,
+	"swe": true, "tam": true, "tel": true, "tha": true, "tur": true,
+	"ukr": true, "urd": true, "vie": true, "cym": true, "wel": true,
+	"eus": true, "baq": true, "glg": true, "gle": true, "lat": true,
+	"mul": true, "und": true, "zxx": true,
+}
+
+func validateLanguages(flag string, codes []string) error {
+	for _, code := range codes {
+		if !languageCodes[code] {
+			return fmt.Errorf("unknown language code for %s: %q (use ISO 639-2 codes such as eng, spa, jpn, und)", flag, code)
+		}
+	}
+	return nil
+}
+
+// languageArgs tags the output's audio and subtitle streams, in order, with
+// the given languages.
+func languageArgs(opts *Options) []string {
+	var args []string
+	for i, code := range opts.AudioLanguages {
+		args = append(args, "-metadata:s:a:"+strconv.Itoa(i), "language="+code)
+	}
+	for i, code := range opts.SubtitleLanguages {
+		args = append(args, "-metadata:s:s:"+strconv.Itoa(i), "language="+code)
+	}
+	return args
+}
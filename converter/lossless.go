@@ -0,0 +1,62 @@
+package converter
+
+import "slices"
+
+// losslessPixelFormats lists the pixel formats each encoder can store
+// losslessly. The source format is kept when it is listed, so the output
+// isn't silently chroma subsampled or converted from RGB.
+var losslessPixelFormats = map[string][]string{
+	"libx264": {
+		"yuv420p", "yuvj420p", "yuv422p", "yuvj422p", "yuv444p", "yuvj444p", "nv12", "nv16", "nv21", "gray",
+		"yuv420p10le", "yuv422p10le", "yuv444p10le", "gray10le",
+	},
+	"libx265": {
+		"yuv420p", "yuv422p", "yuv444p", "gbrp", "gray",
+		"yuv420p10le", "yuv422p10le", "yuv444p10le", "gbrp10le", "gray10le",
+		"yuv420p12le", "yuv422p12le", "yuv444p12le", "gbrp12le", "gray12le",
+	},
+	"libvpx-vp9": {
+		"yuv420p", "yuva420p", "yuv422p", "yuv440p", "yuv444p", "gbrp",
+		"yuv420p10le", "yuv422p10le", "yuv440p10le", "yuv444p10le", "gbrp10le",
+		"yuv420p12le", "yuv422p12le", "yuv440p12le", "yuv444p12le", "gbrp12le",
+	},
+}
+
+// losslessVideoArgs selects each encoder's true lossless mode: CRF 0 is
+// only lossless for 8-bit x264, so x264 gets -qp 0 and VP9 -lossless 1.
+// x265 is switched through its params (see x265Params).
+func losslessVideoArgs(codec string, src *ProbeInfo) []string {
+	var args []string
+	switch codec {
+	case "libx264":
+		args = []string{"-qp", "0"}
+	case "libvpx-vp9":
+		args = []string{"-lossless", "1"}
+	}
+	if pixFmt, ok := losslessPixelFormat(codec, src); ok {
+		args = append(args, "-pix_fmt", pixFmt)
+	}
+	return args
+}
+
+// losslessPixelFormat returns the source's pixel format if codec can store
+// it losslessly.
+func losslessPixelFormat(codec string, src *ProbeInfo) (string, bool) {
+	v := src.VideoStream()
+	if v == nil || v.PixelFormat == "" {
+		return "", false
+	}
+	return v.PixelFormat, slices.Contains(losslessPixelFormats[codec], v.PixelFormat)
+}
+
+// x265Params returns the -x265-params value, turning on x265's lossless
+// mode ahead of the user's own params for lossless quality.
+func x265Params(opts *Options) string {
+	if opts.Quality != QualityLossless {
+		return opts.X265Params
+	}
+	if opts.X265Params == "" {
+		return "lossless=1"
+	}
+	return "lossless=1:" + opts.X265Params
+}
@@ -0,0 +1,120 @@
+package converter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultTargetTruePeak = -1.5
+	defaultTargetLRA      = 11.0
+)
+
+// loudnessMeasurement is what loudnorm's first pass reports about the
+// input, passed back to it verbatim in the second pass.
+type loudnessMeasurement struct {
+	I      string `json:"input_i"`
+	TP     string `json:"input_tp"`
+	LRA    string `json:"input_lra"`
+	Thresh string `json:"input_thresh"`
+	Offset string `json:"target_offset"`
+}
+
+func validateLoudness(opts *Options) error {
+	if opts.TargetLUFS == 0 {
+		if opts.TargetTruePeak != 0 || opts.TargetLRA != 0 || opts.LoudnessTwoPass {
+			return fmt.Errorf("--target-tp, --target-lra and --two-pass-loudness require --target-lufs")
+		}
+		return nil
+	}
+	switch {
+	case opts.TargetLUFS < -70 || opts.TargetLUFS > -5:
+		return fmt.Errorf("invalid target loudness: %g LUFS (must be between -70 and -5, e.g. -16 or -23)", opts.TargetLUFS)
+	case opts.TargetTruePeak < -9 || opts.TargetTruePeak > 0:
+		return fmt.Errorf("invalid target true peak: %g dBTP (must be between -9 and 0)", opts.TargetTruePeak)
+	case opts.TargetLRA != 0 && (opts.TargetLRA < 1 || opts.TargetLRA > 50):
+		return fmt.Errorf("invalid target loudness range: %g LU (must be between 1 and 50)", opts.TargetLRA)
+	case opts.LoudnessTwoPass && opts.BackgroundAudio != "":
+		return fmt.Errorf("--two-pass-loudness cannot be combined with --background-audio")
+	}
+	return nil
+}
+
+// loudnormFilter normalizes to TargetLUFS. Given the first pass's
+// measurement, loudnorm applies a single linear gain instead of adjusting
+// dynamically, which is more accurate and keeps the dynamics intact.
+func loudnormFilter(opts *Options, measured *loudnessMeasurement) string {
+	tp := opts.TargetTruePeak
+	if tp == 0 {
+		tp = defaultTargetTruePeak
+	}
+	lra := opts.TargetLRA
+	if lra == 0 {
+		lra = defaultTargetLRA
+	}
+	filter := "loudnorm=I=" + strconv.FormatFloat(opts.TargetLUFS, 'f', -1, 64) +
+		":TP=" + strconv.FormatFloat(tp, 'f', -1, 64) +
+		":LRA=" + strconv.FormatFloat(lra, 'f', -1, 64)
+	if measured != nil {
+		filter += ":measured_I=" + measured.I + ":measured_TP=" + measured.TP +
+			":measured_LRA=" + measured.LRA + ":measured_thresh=" + measured.Thresh +
+			":offset=" + measured.Offset + ":linear=true"
+	}
+	return filter
+}
+
+// measureLoudness runs loudnorm's first pass over the audio the conversion
+// will normalize: the soundtrack that replaces the input's, if any, after
+// the filters that run before loudnorm.
+func (c *Converter) measureLoudness(ctx context.Context, opts *Options, src *ProbeInfo) (*loudnessMeasurement, error) {
+	input := opts.Input
+	if opts.ReplaceAudio != "" {
+		input = opts.ReplaceAudio
+	} else if opts.Audio != "" {
+		input = opts.Audio
+	}
+
+	// Seek the way the conversion does, so filters see the same timestamps.
+	seek := opts.Start > 0 && input == opts.Input
+	args := []string{"-hide_banner"}
+	if seek && !opts.AccurateSeek {
+		args = append(args, "-ss", formatSeconds(opts.Start))
+	}
+	args = append(args, "-i", input)
+	if seek && opts.AccurateSeek {
+		args = append(args, "-ss", formatSeconds(opts.Start))
+	}
+	if limit := outputLimit(opts, src); limit > 0 {
+		args = append(args, "-t", formatSeconds(limit))
+	}
+	filters := append(preLoudnessFilters(opts, src), loudnormFilter(opts, nil)+":print_format=json")
+	args = append(args, "-map", "0:a:0", "-af", strings.Join(filters, ","), "-vn", "-f", "null", "-")
+
+	out, err := c.combinedOutputNiced(exec.CommandContext(ctx, c.ffmpeg(), args...), opts.Nice)
+	if err != nil {
+		return nil, fmt.Errorf("loudness measurement failed: %w\n%s", err, lastLines(string(out), 5))
+	}
+	return parseLoudnessMeasurement(string(out))
+}
+
+// parseLoudnessMeasurement reads the JSON block loudnorm prints last.
+func parseLoudnessMeasurement(log string) (*loudnessMeasurement, error) {
+	start := strings.LastIndex(log, "{")
+	end := strings.LastIndex(log, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("loudness measurement failed: no loudnorm statistics in ffmpeg output")
+	}
+	var m loudnessMeasurement
+	if err := json.Unmarshal([]byte(log[start:end+1]), &m); err != nil {
+		return nil, fmt.Errorf("failed to parse loudnorm statistics: %w", err)
+	}
+	// Digital silence measures as -inf, which loudnorm can't take back.
+	if strings.Contains(m.I, "inf") {
+		return nil, fmt.Errorf("loudness measurement failed: the audio is silent")
+	}
+	return &m, nil
+}
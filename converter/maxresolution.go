@@ -0,0 +1,69 @@
+package converter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func validateMaxResolution(opts *Options) error {
+	if !isValidResolution(opts.MaxResolution) {
+		return fmt.Errorf("invalid max resolution: %s (examples: 1080p, 720p, or 1920x1080)", opts.MaxResolution)
+	}
+	if opts.Resolution != "" {
+		return fmt.Errorf("--max-resolution cannot be combined with --resolution")
+	}
+	if opts.SkipProbe {
+		return fmt.Errorf("--max-resolution needs the input's size and cannot be combined with --skip-probe")
+	}
+	return nil
+}
+
+// cappedSize returns the size the video is scaled down to so it fits
+// MaxResolution, keeping its aspect ratio, and false if it already fits.
+// A preset like 1080p caps the short side, so portrait videos are treated
+// like landscape ones; WxH is a box the picture must fit in.
+func cappedSize(opts *Options, src *ProbeInfo) (width, height int, ok bool) {
+	v := src.VideoStream()
+	if opts.MaxResolution == "" || v == nil || v.Width == 0 || v.Height == 0 {
+		return 0, 0, false
+	}
+	// Filters see the picture turned upright.
+	w, h := v.Width, v.Height
+	if v.Rotation%180 == 90 {
+		w, h = h, w
+	}
+
+	var ratio float64
+	if maxW, maxH, box := strings.Cut(opts.MaxResolution, "x"); box {
+		bw, _ := strconv.Atoi(maxW)
+		bh, _ := strconv.Atoi(maxH)
+		ratio = min(float64(bw)/float64(w), float64(bh)/float64(h))
+	} else {
+		short, _ := strconv.Atoi(strings.TrimSuffix(opts.MaxResolution, "p"))
+		ratio = float64(short) / float64(min(w, h))
+	}
+	if ratio >= 1 {
+		return 0, 0, false
+	}
+	return evenDimension(float64(w) * ratio), evenDimension(float64(h) * ratio), true
+}
+
+// evenDimension rounds to the nearest even size, as yuv420p encoders need.
+func evenDimension(x float64) int {
+	return max(int(x/2+0.5)*2, 2)
+}
+
+// maxResolutionFilter scales the video down to cappedSize, or returns ""
+// when it already fits.
+func maxResolutionFilter(opts *Options, src *ProbeInfo) string {
+	w, h, ok := cappedSize(opts, src)
+	if !ok {
+		return ""
+	}
+	scale := fmt.Sprintf("scale=%d:%d", w, h)
+	if opts.ScaleAlgorithm != "" {
+		scale += ":flags=" + opts.ScaleAlgorithm
+	}
+	return scale
+}
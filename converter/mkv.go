@@ -0,0 +1,70 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+var coverArtMimeTypes = map[string]string{
+	"jpg":  "image/jpeg",
+	"jpeg": "image/jpeg",
+	"png":  "image/png",
+}
+
+func validateMKVOptions(opts *Options) error {
+	if opts.CoverArt != "" {
+		if opts.Format != "mkv" {
+			return fmt.Errorf("--cover-art is only supported for mkv output")
+		}
+		if err := validateCoverArt(opts.CoverArt); err != nil {
+			return err
+		}
+	}
+
+	if opts.DefaultAudioTrack != nil {
+		if opts.Format != "mkv" {
+			return fmt.Errorf("--default-audio-track is only supported for mkv output")
+		}
+		if *opts.DefaultAudioTrack < 0 {
+			return fmt.Errorf("invalid default audio track: %d (must be 0 or greater)", *opts.DefaultAudioTrack)
+		}
+	}
+	return nil
+}
+
+func validateCoverArt(path string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return fmt.Errorf("cover art file does not exist: %s", path)
+	}
+	if _, ok := coverArtMimeTypes[getExtension(path)]; !ok {
+		return fmt.Errorf("unsupported cover art format: %s (supported: jpg, png)", path)
+	}
+	return nil
+}
+
+// mkvArgs attaches the cover art under the name players look for and, when
+// a default audio track is chosen, keeps every audio track so there is
+// something to choose between (MapAll already does).
+func mkvArgs(opts *Options) []string {
+	var args []string
+	if opts.CoverArt != "" {
+		ext := getExtension(opts.CoverArt)
+		args = append(args,
+			"-attach", opts.CoverArt,
+			"-metadata:s:t", "mimetype="+coverArtMimeTypes[ext],
+			"-metadata:s:t", "filename=cover"+filepath.Ext(opts.CoverArt),
+		)
+	}
+	if opts.DefaultAudioTrack != nil {
+		if !opts.MapAll {
+			args = append(args, "-map", "0:v:0", "-map", "0:a")
+		}
+		args = append(args,
+			"-disposition:a", "0",
+			"-disposition:a:"+strconv.Itoa(*opts.DefaultAudioTrack), "default",
+		)
+	}
+	return args
+}
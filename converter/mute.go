@@ -0,0 +1,92 @@
+package converter
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+type timeRange struct {
+	Start, End time.Duration
+}
+
+// parseTimeRange parses "start-end", each side a [[hh:]mm:]ss[.fff]
+// timestamp, e.g. "1:05-1:12.5".
+func parseTimeRange(s string) (timeRange, error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return timeRange{}, fmt.Errorf("invalid time range %q (expected start-end, e.g. 1:05-1:12)", s)
+	}
+	start, err1 := parseTimestamp(strings.TrimSpace(from))
+	end, err2 := parseTimestamp(strings.TrimSpace(to))
+	if err1 != nil || err2 != nil {
+		return timeRange{}, fmt.Errorf("invalid time range %q (expected start-end, e.g. 1:05-1:12)", s)
+	}
+	if end <= start {
+		return timeRange{}, fmt.Errorf("invalid time range %q (end must come after start)", s)
+	}
+	return timeRange{Start: start, End: end}, nil
+}
+
+// muteRanges parses opts.Mute, sorted by start and with overlapping or
+// touching ranges merged.
+func muteRanges(opts *Options) ([]timeRange, error) {
+	var ranges []timeRange
+	for _, s := range opts.Mute {
+		r, err := parseTimeRange(s)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+	slices.SortFunc(ranges, func(a, b timeRange) int { return cmp.Compare(a.Start, b.Start) })
+
+	var merged []timeRange
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && r.Start <= merged[n-1].End {
+			merged[n-1].End = max(merged[n-1].End, r.End)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged, nil
+}
+
+// validateMuteRanges rejects ranges that start past the end of the input.
+func validateMuteRanges(opts *Options, src *ProbeInfo) error {
+	if src == nil || src.Duration <= 0 {
+		return nil
+	}
+	ranges, err := muteRanges(opts)
+	if err != nil {
+		return err
+	}
+	for _, r := range ranges {
+		if r.Start >= src.Duration {
+			return fmt.Errorf("mute range %s-%s is past the end of %s (%s)", formatSeconds(r.Start), formatSeconds(r.End), opts.Input, src.Duration.Round(time.Millisecond))
+		}
+	}
+	return nil
+}
+
+// muteFilters silences each range with a volume filter. Ranges are times
+// in the input; a fast seek restarts the audio's clock at Start, so they
+// are shifted to match.
+func muteFilters(opts *Options) []string {
+	ranges, _ := muteRanges(opts)
+	var offset time.Duration
+	if opts.Start > 0 && !opts.AccurateSeek {
+		offset = opts.Start
+	}
+	var filters []string
+	for _, r := range ranges {
+		if r.End <= offset {
+			continue
+		}
+		filters = append(filters, fmt.Sprintf("volume=enable='between(t,%s,%s)':volume=0",
+			formatSeconds(max(r.Start-offset, 0)), formatSeconds(r.End-offset)))
+	}
+	return filters
+}
@@ -0,0 +1,78 @@
+package converter
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var placeholderRegex = regexp.MustCompile(`\{(\w+)\}`)
+
+var templatePlaceholders = map[string]bool{
+	"name":    true,
+	"ext":     true,
+	"quality": true,
+	"codec":   true,
+	"width":   true,
+	"height":  true,
+}
+
+func validateNameTemplate(tmpl string) error {
+	for _, m := range placeholderRegex.FindAllStringSubmatch(tmpl, -1) {
+		if !templatePlaceholders[m[1]] {
+			return fmt.Errorf("unknown placeholder in name template: {%s} (supported: {name}, {ext}, {quality}, {codec}, {width}, {height})", m[1])
+		}
+	}
+	return nil
+}
+
+// expandNameTemplate builds the output path for opts from its name template.
+// The result is placed next to the input; separators in the template itself
+// are kept so templates can target subdirectories.
+func (c *Converter) expandNameTemplate(opts *Options) string {
+	var src *StreamInfo
+	if strings.Contains(opts.NameTemplate, "{width}") || strings.Contains(opts.NameTemplate, "{height}") {
+		if info, err := c.Probe(opts.Input); err == nil {
+			src = info.VideoStream()
+		}
+	}
+
+	name := placeholderRegex.ReplaceAllStringFunc(opts.NameTemplate, func(p string) string {
+		switch p {
+		case "{name}":
+			return filepath.Base(trimExtension(opts.Input))
+		case "{ext}":
+			return opts.Format
+		case "{quality}":
+			return string(opts.Quality)
+		case "{codec}":
+			return codecName(opts)
+		case "{width}":
+			if src != nil {
+				return strconv.Itoa(src.Width)
+			}
+		case "{height}":
+			if src != nil {
+				return strconv.Itoa(src.Height)
+			}
+		}
+		return "unknown"
+	})
+
+	return filepath.Join(filepath.Dir(opts.Input), name)
+}
+
+func codecName(opts *Options) string {
+	if opts.Codec != "" {
+		return opts.Codec
+	}
+	codec := videoCodec(opts)
+	for name, c := range codecMap {
+		if c == codec {
+			return name
+		}
+	}
+	return codec
+}
@@ -0,0 +1,82 @@
+package converter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// presetBundles are task-oriented option sets selected with PresetBundle.
+// Fields set explicitly on Options take precedence over the bundle.
+var presetBundles = map[string]Options{
+	"youtube": {
+		Format:        "mp4",
+		Codec:         "h264",
+		Quality:       QualityHigh,
+		Profile:       "high",
+		KeyframeEvery: 2 * time.Second,
+		SampleRate:    48000,
+	},
+	"archive": {
+		Format:  "mkv",
+		Codec:   "h265",
+		Quality: QualityHigh,
+	},
+	"web": {
+		Format:     "mp4",
+		Codec:      "h264",
+		Quality:    QualityMedium,
+		Resolution: "1080p",
+		Profile:    "main",
+	},
+	"discord": {
+		Format:     "mp4",
+		Codec:      "h264",
+		Quality:    QualityLow,
+		Resolution: "720p",
+		Profile:    "main",
+	},
+}
+
+// PresetBundles returns the names of the available preset bundles.
+func PresetBundles() []string {
+	names := make([]string, 0, len(presetBundles))
+	for name := range presetBundles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func validatePresetBundle(name string) error {
+	if _, ok := presetBundles[name]; !ok {
+		return fmt.Errorf("unsupported preset bundle: %s (supported: %s)", name, strings.Join(PresetBundles(), ", "))
+	}
+	return nil
+}
+
+// applyPresetBundle fills the fields opts leaves unset from its bundle.
+// The bundle's codec and profile go together: an explicit codec replaces
+// both, and an explicit format drops them if it can't hold the codec.
+func applyPresetBundle(opts *Options) {
+	bundle, ok := presetBundles[opts.PresetBundle]
+	if !ok {
+		return
+	}
+	if opts.Codec != "" || (opts.Format != "" && !containerHolds(opts.Format, codecMap[bundle.Codec])) {
+		bundle.Codec = ""
+		bundle.Profile = ""
+	}
+	if opts.Codec != "" && opts.Format == "" && !containerHolds(bundle.Format, codecMap[opts.Codec]) {
+		bundle.Format = ""
+	}
+	applyDefaults(opts, &bundle)
+}
+
+// containerHolds reports whether format can store video from encoder.
+// Formats fk-converter doesn't restrict hold anything.
+func containerHolds(format, encoder string) bool {
+	allowed, ok := containerCodecs[format]
+	return !ok || slices.Contains(allowed, encoder)
+}
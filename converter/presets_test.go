@@ -0,0 +1,34 @@
+package converter
+
+import "testing"
+
+func TestPresetBundleOverrides(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		format  string
+		codec   string
+		profile string
+	}{
+		{"bundle alone", Options{PresetBundle: "youtube"}, "mp4", "h264", "high"},
+		{"explicit codec", Options{PresetBundle: "youtube", Codec: "h265"}, "mp4", "h265", ""},
+		{"explicit vp9", Options{PresetBundle: "web", Codec: "vp9"}, "mp4", "vp9", ""},
+		{"explicit format", Options{PresetBundle: "web", Format: "webm"}, "webm", "", ""},
+		{"format that holds the codec", Options{PresetBundle: "web", Format: "mkv"}, "mkv", "h264", "main"},
+		{"explicit profile", Options{PresetBundle: "youtube", Codec: "h265", Profile: "main10"}, "mp4", "h265", "main10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.Input = writeInput(t, "clip.mov")
+			ResolveOutput(&opts)
+			if opts.Format != tt.format || opts.Codec != tt.codec || opts.Profile != tt.profile {
+				t.Errorf("format %q, codec %q, profile %q; want %q, %q, %q",
+					opts.Format, opts.Codec, opts.Profile, tt.format, tt.codec, tt.profile)
+			}
+			if err := ValidateOptions(&opts); err != nil {
+				t.Errorf("ValidateOptions: %v", err)
+			}
+		})
+	}
+}
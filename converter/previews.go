@@ -0,0 +1,172 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// PreviewsOptions describes scrub bar thumbnails for web players: sprite
+// sheets of frames taken every Interval, and a WebVTT file (Output)
+// mapping each stretch of time to its region of a sprite.
+type PreviewsOptions struct {
+	Input  string
+	Output string
+
+	Interval   time.Duration
+	ThumbWidth int
+
+	// Columns and Rows are the number of thumbnails per sprite sheet.
+	Columns int
+	Rows    int
+}
+
+const (
+	defaultPreviewInterval   = 10 * time.Second
+	defaultPreviewThumbWidth = 160
+	defaultPreviewGrid       = 10
+)
+
+// Previews is the result of GeneratePreviews.
+type Previews struct {
+	VTT     string
+	Sprites []string
+}
+
+func GeneratePreviews(opts *PreviewsOptions, onProgress ProgressFunc) (*Previews, error) {
+	return defaultConverter.GeneratePreviews(context.Background(), opts, onProgress)
+}
+
+// ResolvePreviewsOptions fills in the defaults: a thumbnail every 10s,
+// 160px wide, 10x10 per sprite, and name_previews.vtt next to the input.
+func ResolvePreviewsOptions(opts *PreviewsOptions) {
+	if opts.Output == "" {
+		opts.Output = trimExtension(opts.Input) + "_previews.vtt"
+	}
+	if opts.Interval == 0 {
+		opts.Interval = defaultPreviewInterval
+	}
+	if opts.ThumbWidth == 0 {
+		opts.ThumbWidth = defaultPreviewThumbWidth
+	}
+	if opts.Columns == 0 {
+		opts.Columns = defaultPreviewGrid
+	}
+	if opts.Rows == 0 {
+		opts.Rows = defaultPreviewGrid
+	}
+}
+
+// ValidatePreviewsOptions checks opts before running. Every error it
+// returns matches ErrInvalidOptions.
+func ValidatePreviewsOptions(opts *PreviewsOptions) error {
+	return invalidOptions(validatePreviewsOptions(opts))
+}
+
+func validatePreviewsOptions(opts *PreviewsOptions) error {
+	if _, err := os.Stat(opts.Input); os.IsNotExist(err) {
+		return fmt.Errorf("input file does not exist: %s", opts.Input)
+	}
+	if getExtension(opts.Output) != "vtt" {
+		return fmt.Errorf("unsupported previews output: %s (must be a .vtt file)", opts.Output)
+	}
+	if opts.Interval < 100*time.Millisecond {
+		return fmt.Errorf("invalid interval: %s (must be at least 100ms)", opts.Interval)
+	}
+	if opts.ThumbWidth < 16 || opts.ThumbWidth%2 != 0 {
+		return fmt.Errorf("invalid thumbnail width: %d (must be an even number of at least 16)", opts.ThumbWidth)
+	}
+	if opts.Columns <= 0 || opts.Rows <= 0 {
+		return fmt.Errorf("invalid sprite grid: %dx%d (must be positive)", opts.Columns, opts.Rows)
+	}
+	return nil
+}
+
+// GeneratePreviews writes the sprite sheets next to the VTT file, named
+// after it (name_previews_001.jpg, ...), and the VTT file referencing them
+// by file name.
+func (c *Converter) GeneratePreviews(ctx context.Context, opts *PreviewsOptions, onProgress ProgressFunc) (*Previews, error) {
+	ResolvePreviewsOptions(opts)
+	if err := ValidatePreviewsOptions(opts); err != nil {
+		return nil, err
+	}
+
+	info, err := c.Probe(opts.Input)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read input %s, it may be corrupt or not a media file: %w", opts.Input, err)
+	}
+	v := info.VideoStream()
+	if v == nil || v.Width == 0 || v.Height == 0 {
+		return nil, fmt.Errorf("input has no video: %s", opts.Input)
+	}
+	if info.Duration <= 0 {
+		return nil, fmt.Errorf("cannot generate previews: the duration of %s is unknown", opts.Input)
+	}
+
+	// ffmpeg rotates the frames upright before scaling them.
+	width, height := v.Width, v.Height
+	if v.Rotation == 90 || v.Rotation == 270 {
+		width, height = height, width
+	}
+	thumbHeight := int(math.Round(float64(opts.ThumbWidth)*float64(height)/float64(width)/2)) * 2
+
+	spritePattern := trimExtension(opts.Output) + "_%03d.jpg"
+	if err := os.MkdirAll(filepath.Dir(opts.Output), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	filter := fmt.Sprintf("fps=1/%s,scale=%d:%d,tile=%dx%d",
+		formatSeconds(opts.Interval), opts.ThumbWidth, thumbHeight, opts.Columns, opts.Rows)
+	args := []string{
+		"-i", opts.Input, "-y", "-progress", "pipe:2", "-nostats",
+		"-an", "-vf", filter,
+		"-q:v", "5", "-start_number", "1",
+		spritePattern,
+	}
+	if err := c.runFFmpeg(ctx, args, progressTotal{duration: info.Duration}, progressReporter(onProgress, nil)); err != nil {
+		return nil, fmt.Errorf("ffmpeg previews failed: %w", err)
+	}
+
+	thumbs := int(math.Ceil(float64(info.Duration) / float64(opts.Interval)))
+	perSprite := opts.Columns * opts.Rows
+	result := &Previews{VTT: opts.Output}
+	for i := 1; i <= (thumbs+perSprite-1)/perSprite; i++ {
+		result.Sprites = append(result.Sprites, fmt.Sprintf(spritePattern, i))
+	}
+
+	vtt := buildPreviewsVTT(opts, info.Duration, thumbs, thumbHeight, filepath.Base(spritePattern))
+	if err := os.WriteFile(opts.Output, []byte(vtt), 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", opts.Output, err)
+	}
+	return result, nil
+}
+
+// buildPreviewsVTT maps each interval to its thumbnail's region of a sprite
+// with a media fragment (#xywh=x,y,w,h). Sprites fill row by row.
+func buildPreviewsVTT(opts *PreviewsOptions, duration time.Duration, thumbs, thumbHeight int, spritePattern string) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+
+	perSprite := opts.Columns * opts.Rows
+	for i := range thumbs {
+		start := time.Duration(i) * opts.Interval
+		end := min(start+opts.Interval, duration)
+		pos := i % perSprite
+		x := pos % opts.Columns * opts.ThumbWidth
+		y := pos / opts.Columns * thumbHeight
+
+		fmt.Fprintf(&b, "\n%s --> %s\n", vttTimestamp(start), vttTimestamp(end))
+		fmt.Fprintf(&b, "%s#xywh=%d,%d,%d,%d\n", fmt.Sprintf(spritePattern, i/perSprite+1), x, y, opts.ThumbWidth, thumbHeight)
+	}
+	return b.String()
+}
+
+// vttTimestamp formats d as HH:MM:SS.mmm.
+func vttTimestamp(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
@@ -0,0 +1,44 @@
+package converter
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+)
+
+const maxNice = 19
+
+func validateNice(nice int) error {
+	if nice < 0 || nice > maxNice {
+		return fmt.Errorf("invalid niceness: %d (must be 0-%d, higher is lower priority)", nice, maxNice)
+	}
+	if nice > 0 && !prioritySupported {
+		return fmt.Errorf("--nice is not supported on this platform (Unix only)")
+	}
+	return nil
+}
+
+// lowerPriority sets the niceness of a started ffmpeg process. Failing to
+// is only worth a warning: the conversion works either way.
+func (c *Converter) lowerPriority(cmd *exec.Cmd, nice int) {
+	if nice == 0 {
+		return
+	}
+	if err := setPriority(cmd.Process.Pid, nice); err != nil {
+		c.warn("could not lower the priority of ffmpeg to nice %d: %v", nice, err)
+	}
+}
+
+// combinedOutputNiced is cmd.CombinedOutput for the analysis passes of a
+// conversion, run at the same niceness as its encode.
+func (c *Converter) combinedOutputNiced(cmd *exec.Cmd, nice int) ([]byte, error) {
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	c.lowerPriority(cmd, nice)
+	err := cmd.Wait()
+	return out.Bytes(), err
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package converter
+
+import "errors"
+
+// Windows has priority classes instead of niceness; ValidateOptions
+// rejects Nice there.
+const prioritySupported = false
+
+func setPriority(pid, nice int) error {
+	return errors.New("process priority is not supported on this platform")
+}
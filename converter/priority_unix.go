@@ -0,0 +1,13 @@
+//go:build unix
+
+package converter
+
+import "syscall"
+
+const prioritySupported = true
+
+// setPriority sets the niceness of process pid. On Linux the I/O priority
+// of the default best-effort class follows it.
+func setPriority(pid, nice int) error {
+	return syscall.Setpriority(syscall.PRIO_PROCESS, pid, nice)
+}
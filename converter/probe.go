@@ -0,0 +1,273 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ProbeInfo struct {
+	FormatName string
+	Duration   time.Duration
+	Size       int64
+	BitRate    int64
+	Streams    []StreamInfo
+	Chapters   []ChapterInfo
+
+	// CreationTime is when the media was recorded, from the container's
+	// creation_time tag; zero if untagged.
+	CreationTime time.Time
+}
+
+type StreamInfo struct {
+	Index      int
+	Type       string
+	Codec      string
+	Width      int
+	Height     int
+	Channels   int
+	SampleRate int
+	Language   string
+	Title      string
+	FrameRate  float64
+	Frames     int64
+
+	// RealFrameRate is the stream's base rate (r_frame_rate). It differs
+	// from the average FrameRate in variable frame rate sources.
+	RealFrameRate float64
+
+	// Color metadata as tagged in the file (e.g. bt709), empty if untagged.
+	ColorSpace     string
+	ColorPrimaries string
+	ColorTransfer  string
+
+	// PixelFormat is the decoded pixel layout, e.g. yuv420p or rgb24.
+	PixelFormat string
+
+	// Rotation is how far the video must be turned clockwise to display
+	// upright: 0, 90, 180 or 270. Phones record sideways and set this.
+	Rotation int
+}
+
+// ChapterInfo is a chapter embedded in the input.
+type ChapterInfo struct {
+	Start time.Duration
+	End   time.Duration
+	Title string
+}
+
+type ffprobeOutput struct {
+	Format struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+		Size       string `json:"size"`
+		BitRate    string `json:"bit_rate"`
+		Tags       struct {
+			CreationTime string `json:"creation_time"`
+		} `json:"tags"`
+	} `json:"format"`
+	Streams []struct {
+		Index        int    `json:"index"`
+		CodecType    string `json:"codec_type"`
+		CodecName    string `json:"codec_name"`
+		Width        int    `json:"width"`
+		Height       int    `json:"height"`
+		Channels     int    `json:"channels"`
+		SampleRate   string `json:"sample_rate"`
+		AvgFrameRate string `json:"avg_frame_rate"`
+		RFrameRate   string `json:"r_frame_rate"`
+		NbFrames     string `json:"nb_frames"`
+		ColorSpace   string `json:"color_space"`
+		ColorPrimary string `json:"color_primaries"`
+		ColorTrc     string `json:"color_transfer"`
+		PixFmt       string `json:"pix_fmt"`
+		Tags         struct {
+			Language string `json:"language"`
+			Title    string `json:"title"`
+			Rotate   string `json:"rotate"`
+		} `json:"tags"`
+		SideDataList []struct {
+			Rotation *float64 `json:"rotation"`
+		} `json:"side_data_list"`
+	} `json:"streams"`
+	Chapters []struct {
+		StartTime string `json:"start_time"`
+		EndTime   string `json:"end_time"`
+		Tags      struct {
+			Title string `json:"title"`
+		} `json:"tags"`
+	} `json:"chapters"`
+}
+
+// probeInput probes the input before converting it, rejecting files that
+// exist but hold no audio or video (truncated downloads, an HTML page saved
+// as .mp4, ...). With SkipProbe it returns nil without running ffprobe.
+func (c *Converter) probeInput(opts *Options) (*ProbeInfo, error) {
+	if opts.SkipProbe {
+		return nil, nil
+	}
+	info, err := c.Probe(opts.Input)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read input %s, it may be corrupt or not a media file: %w", opts.Input, err)
+	}
+	if len(info.StreamsOfType("video")) == 0 && len(info.StreamsOfType("audio")) == 0 {
+		return nil, fmt.Errorf("file exists but contains no media streams: %s", opts.Input)
+	}
+	return info, nil
+}
+
+func Probe(path string) (*ProbeInfo, error) {
+	return defaultConverter.Probe(path)
+}
+
+func (c *Converter) Probe(path string) (*ProbeInfo, error) {
+	cmd := exec.Command(c.ffprobe(),
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		"-show_chapters",
+		path,
+	)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe failed on %s: %w", path, err)
+	}
+
+	var raw ffprobeOutput
+	if err := json.Unmarshal(out, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+
+	info := &ProbeInfo{
+		FormatName: raw.Format.FormatName,
+		Size:       parseInt(raw.Format.Size),
+		BitRate:    parseInt(raw.Format.BitRate),
+	}
+	if seconds, err := strconv.ParseFloat(raw.Format.Duration, 64); err == nil {
+		info.Duration = time.Duration(seconds * float64(time.Second))
+	}
+	if t, err := time.Parse(time.RFC3339Nano, raw.Format.Tags.CreationTime); err == nil {
+		info.CreationTime = t
+	}
+
+	for _, s := range raw.Streams {
+		info.Streams = append(info.Streams, StreamInfo{
+			Index:      s.Index,
+			Type:       s.CodecType,
+			Codec:      s.CodecName,
+			Width:      s.Width,
+			Height:     s.Height,
+			Channels:   s.Channels,
+			SampleRate: int(parseInt(s.SampleRate)),
+			Language:   s.Tags.Language,
+			Title:      s.Tags.Title,
+			FrameRate:  parseRate(s.AvgFrameRate),
+			Frames:     parseInt(s.NbFrames),
+
+			RealFrameRate: parseRate(s.RFrameRate),
+
+			ColorSpace:     s.ColorSpace,
+			ColorPrimaries: s.ColorPrimary,
+			ColorTransfer:  s.ColorTrc,
+
+			PixelFormat: s.PixFmt,
+		})
+
+		// Newer ffmpeg reports a display matrix, whose angle is
+		// counterclockwise; older files carry a clockwise rotate tag.
+		stream := &info.Streams[len(info.Streams)-1]
+		stream.Rotation = normalizeRotation(int(parseInt(s.Tags.Rotate)))
+		for _, sd := range s.SideDataList {
+			if sd.Rotation != nil {
+				stream.Rotation = normalizeRotation(-int(math.Round(*sd.Rotation)))
+			}
+		}
+	}
+
+	for _, ch := range raw.Chapters {
+		info.Chapters = append(info.Chapters, ChapterInfo{
+			Start: parseSeconds(ch.StartTime),
+			End:   parseSeconds(ch.EndTime),
+			Title: ch.Tags.Title,
+		})
+	}
+
+	return info, nil
+}
+
+// normalizeRotation maps an angle in degrees to 0, 90, 180 or 270.
+func normalizeRotation(degrees int) int {
+	return ((degrees % 360) + 360) % 360 / 90 * 90
+}
+
+// VideoStream returns the first video stream, or nil if there is none or
+// p itself is nil (the input could not be probed).
+func (p *ProbeInfo) VideoStream() *StreamInfo {
+	if p == nil {
+		return nil
+	}
+	for i := range p.Streams {
+		if p.Streams[i].Type == "video" {
+			return &p.Streams[i]
+		}
+	}
+	return nil
+}
+
+func (p *ProbeInfo) StreamsOfType(kind string) []StreamInfo {
+	var streams []StreamInfo
+	for _, s := range p.Streams {
+		if s.Type == kind {
+			streams = append(streams, s)
+		}
+	}
+	return streams
+}
+
+// TotalFrames estimates the number of video frames, preferring the
+// container's frame count and falling back to duration × frame rate.
+func (p *ProbeInfo) TotalFrames() int64 {
+	v := p.VideoStream()
+	if v == nil {
+		return 0
+	}
+	if v.Frames > 0 {
+		return v.Frames
+	}
+	return int64(p.Duration.Seconds() * v.FrameRate)
+}
+
+// IsVFR reports whether the video looks variable frame rate, i.e. its
+// average rate is more than 1% away from its base rate.
+func (p *ProbeInfo) IsVFR() bool {
+	v := p.VideoStream()
+	if v == nil || v.FrameRate <= 0 || v.RealFrameRate <= 0 {
+		return false
+	}
+	return math.Abs(v.FrameRate-v.RealFrameRate)/v.RealFrameRate > 0.01
+}
+
+// parseRate parses ffprobe's fractional rates such as "30000/1001".
+func parseRate(s string) float64 {
+	num, den, ok := strings.Cut(s, "/")
+	if !ok {
+		f, _ := strconv.ParseFloat(s, 64)
+		return f
+	}
+	n, err1 := strconv.ParseFloat(num, 64)
+	d, err2 := strconv.ParseFloat(den, 64)
+	if err1 != nil || err2 != nil || d == 0 {
+		return 0
+	}
+	return n / d
+}
+
+func parseInt(s string) int64 {
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
@@ -0,0 +1,149 @@
+package converter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Progress is the state of a running conversion, updated once per ffmpeg
+// progress report (about twice a second).
+type Progress struct {
+	Percent float64
+	Frame   int64
+	FPS     float64
+
+	// Bitrate is the output bitrate so far in kbit/s.
+	Bitrate float64
+
+	// Speed is the encoding speed as a multiple of realtime, averaged over
+	// the last few reports so the ETA doesn't swing on every update.
+	Speed float64
+	ETA   time.Duration
+}
+
+// progressTotal is what a conversion's progress is measured against. Time
+// is used when the duration is known; the frame count is the fallback for
+// inputs whose duration can't be probed.
+type progressTotal struct {
+	duration time.Duration
+	frames   int64
+}
+
+var progressLineRegex = regexp.MustCompile(`^[a-z_0-9]+=\S*$`)
+
+const speedWindow = 10
+
+type progressTracker struct {
+	total   progressTotal
+	outTime time.Duration
+	speeds  []float64
+	current Progress
+
+	// speed is the latest unsmoothed reading.
+	speed float64
+}
+
+// update applies one key=value line and reports whether it completed a
+// progress block.
+func (t *progressTracker) update(key, value string) bool {
+	switch key {
+	case "frame":
+		t.current.Frame, _ = strconv.ParseInt(value, 10, 64)
+	case "fps":
+		t.current.FPS, _ = strconv.ParseFloat(value, 64)
+	case "bitrate":
+		t.current.Bitrate, _ = strconv.ParseFloat(strings.TrimSuffix(value, "kbits/s"), 64)
+	case "out_time_us":
+		if us, err := strconv.ParseInt(value, 10, 64); err == nil {
+			t.outTime = time.Duration(us) * time.Microsecond
+		}
+	case "speed":
+		t.speed, _ = strconv.ParseFloat(strings.TrimSuffix(value, "x"), 64)
+		if speed := t.speed; speed > 0 {
+			t.speeds = append(t.speeds, speed)
+			if len(t.speeds) > speedWindow {
+				t.speeds = t.speeds[1:]
+			}
+		}
+	case "progress":
+		t.finishBlock()
+		return true
+	}
+	return false
+}
+
+func (t *progressTracker) finishBlock() {
+	switch {
+	case t.total.duration > 0:
+		t.current.Percent = float64(t.outTime) / float64(t.total.duration) * 100
+	case t.total.frames > 0:
+		t.current.Percent = float64(t.current.Frame) / float64(t.total.frames) * 100
+	}
+	t.current.Percent = min(t.current.Percent, 100)
+
+	if len(t.speeds) > 0 {
+		var sum float64
+		for _, s := range t.speeds {
+			sum += s
+		}
+		t.current.Speed = sum / float64(len(t.speeds))
+	}
+
+	t.current.ETA = 0
+	if t.current.Speed > 0 && t.total.duration > t.outTime {
+		remaining := t.total.duration - t.outTime
+		t.current.ETA = time.Duration(float64(remaining) / t.current.Speed).Round(time.Second)
+	}
+}
+
+// progressReporter combines the percentage and detailed callbacks into one,
+// returning nil when there is nobody to report to.
+func progressReporter(onProgress ProgressFunc, onDetail func(Progress)) func(Progress) {
+	if onProgress == nil && onDetail == nil {
+		return nil
+	}
+	return func(p Progress) {
+		if onProgress != nil {
+			onProgress(p.Percent)
+		}
+		if onDetail != nil {
+			onDetail(p)
+		}
+	}
+}
+
+// parseProgress consumes ffmpeg's stderr, reporting progress from the
+// -progress key=value lines and forwarding everything else to log. stats
+// gets every block, even when progress can't be computed.
+func parseProgress(r io.Reader, total progressTotal, report func(Progress), stats StatsFunc, log io.Writer) {
+	tracker := &progressTracker{total: total}
+	canReport := total.duration > 0 || total.frames > 0
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || !progressLineRegex.MatchString(line) {
+			if log != nil {
+				fmt.Fprintln(log, line)
+			}
+			continue
+		}
+
+		if !tracker.update(key, value) {
+			continue
+		}
+		if stats != nil {
+			p := tracker.current
+			stats(p.Frame, p.FPS, p.Bitrate, tracker.speed)
+		}
+		if report != nil && canReport {
+			report(tracker.current)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package converter
+
+import (
+	"fmt"
+	"math"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type QualityScores struct {
+	VMAF float64
+	PSNR float64
+	SSIM float64
+
+	// HasVMAF is false when the ffmpeg build lacks libvmaf.
+	HasVMAF bool
+}
+
+var (
+	vmafRegex = regexp.MustCompile(`VMAF score[:=]\s*([\d.]+)`)
+	psnrRegex = regexp.MustCompile(`PSNR .*average:([\d.]+|inf)`)
+	ssimRegex = regexp.MustCompile(`SSIM .*All:([\d.]+)`)
+)
+
+func (c *Converter) hasFilter(name string) bool {
+	out, err := exec.Command(c.ffmpeg(), "-hide_banner", "-filters").Output()
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[1] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Compare scores a converted video against its original. The converted
+// video is scaled and retimed to the original's resolution and frame rate
+// first, so outputs of a resize or frame rate change can be compared.
+func Compare(reference, distorted string) (*QualityScores, error) {
+	return defaultConverter.Compare(reference, distorted)
+}
+
+func (c *Converter) Compare(reference, distorted string) (*QualityScores, error) {
+	ref, err := c.Probe(reference)
+	if err != nil {
+		return nil, err
+	}
+	dist, err := c.Probe(distorted)
+	if err != nil {
+		return nil, err
+	}
+	if ref.VideoStream() == nil {
+		return nil, fmt.Errorf("%s has no video stream", reference)
+	}
+	if dist.VideoStream() == nil {
+		return nil, fmt.Errorf("%s has no video stream", distorted)
+	}
+	return c.measureQuality(reference, distorted, 0, alignFilter(ref.VideoStream(), dist.VideoStream()))
+}
+
+// alignFilter returns the filters that bring the distorted video to the
+// reference's dimensions and frame rate, or "" if they already match.
+func alignFilter(ref, dist *StreamInfo) string {
+	var filters []string
+	if ref.Width != dist.Width || ref.Height != dist.Height {
+		filters = append(filters, fmt.Sprintf("scale=%d:%d:flags=bicubic", ref.Width, ref.Height))
+	}
+	if ref.FrameRate > 0 && math.Abs(ref.FrameRate-dist.FrameRate) > 0.01 {
+		filters = append(filters, "fps="+strconv.FormatFloat(ref.FrameRate, 'f', 3, 64))
+	}
+	return strings.Join(filters, ",")
+}
+
+// measureQuality scores distorted against the first limit of reference
+// (the whole file when limit is 0). align is applied to distorted first
+// and may be empty when both already have the same dimensions and rate.
+func (c *Converter) measureQuality(reference, distorted string, limit time.Duration, align string) (*QualityScores, error) {
+	scores := &QualityScores{}
+
+	// PSNR and SSIM share one decode of both files.
+	out, err := c.runMetrics(reference, distorted, limit, align, "[d]split[d1][d2];[r]split[r1][r2];[d1][r1]psnr;[d2][r2]ssim", "PSNR/SSIM")
+	if err != nil {
+		return nil, err
+	}
+	if scores.PSNR, err = parseScore(out, "PSNR", psnrRegex); err != nil {
+		return nil, err
+	}
+	if scores.SSIM, err = parseScore(out, "SSIM", ssimRegex); err != nil {
+		return nil, err
+	}
+
+	if c.hasFilter("libvmaf") {
+		out, err := c.runMetrics(reference, distorted, limit, align, "[d][r]libvmaf", "VMAF")
+		if err != nil {
+			return nil, err
+		}
+		if scores.VMAF, err = parseScore(out, "VMAF", vmafRegex); err != nil {
+			return nil, err
+		}
+		scores.HasVMAF = true
+	}
+	return scores, nil
+}
+
+// runMetrics runs a metric filtergraph over the labelled, timestamp-aligned
+// [d]istorted and [r]eference videos and returns ffmpeg's log.
+func (c *Converter) runMetrics(reference, distorted string, limit time.Duration, align, metrics, name string) (string, error) {
+	args := []string{"-hide_banner", "-i", distorted}
+	if limit > 0 {
+		args = append(args, "-t", formatSeconds(limit))
+	}
+
+	dist := "setpts=PTS-STARTPTS"
+	if align != "" {
+		dist = align + "," + dist
+	}
+	graph := "[0:v]" + dist + "[d];[1:v]setpts=PTS-STARTPTS[r];" + metrics
+	args = append(args, "-i", reference, "-lavfi", graph, "-f", "null", "-")
+
+	out, err := exec.Command(c.ffmpeg(), args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s measurement failed: %w\n%s", name, err, lastLines(string(out), 5))
+	}
+	return string(out), nil
+}
+
+func parseScore(log, name string, re *regexp.Regexp) (float64, error) {
+	matches := re.FindStringSubmatch(log)
+	if len(matches) != 2 {
+		return 0, fmt.Errorf("%s measurement produced no score", name)
+	}
+	if matches[1] == "inf" {
+		return 100, nil
+	}
+	return strconv.ParseFloat(matches[1], 64)
+}
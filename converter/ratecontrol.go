@@ -0,0 +1,118 @@
+package converter
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type RateControl string
+
+const (
+	RateControlCRF     RateControl = "crf"
+	RateControlBitrate RateControl = "bitrate"
+)
+
+// bitrateLadder maps an output height to the target video bitrate of each
+// quality preset in bitrate mode.
+var bitrateLadder = map[int]map[Quality]string{
+	2160: {QualityLow: "12M", QualityMedium: "20M", QualityHigh: "35M"},
+	1440: {QualityLow: "6M", QualityMedium: "10M", QualityHigh: "16M"},
+	1080: {QualityLow: "3M", QualityMedium: "5M", QualityHigh: "8M"},
+	720:  {QualityLow: "1.5M", QualityMedium: "2.5M", QualityHigh: "5M"},
+	480:  {QualityLow: "750k", QualityMedium: "1.2M", QualityHigh: "2.5M"},
+	360:  {QualityLow: "400k", QualityMedium: "700k", QualityHigh: "1.2M"},
+}
+
+var resolutionHeightRegex = regexp.MustCompile(`^(?:\d+x)?(\d+)p?$`)
+
+func videoRateArgs(opts *Options, codec string, src *ProbeInfo) []string {
+	if opts.VideoBitrate != "" {
+		return []string{"-b:v", opts.VideoBitrate}
+	}
+	if opts.RateControl == RateControlBitrate {
+		return []string{"-b:v", ladderBitrate(outputHeight(opts, src), opts.Quality)}
+	}
+	if opts.Quality == QualityLossless {
+		return losslessVideoArgs(codec, src)
+	}
+
+	crf := videoCRF(opts, src)
+	if strings.Contains(codec, "vpx") {
+		return []string{"-crf", strconv.Itoa(crf), "-b:v", "0"}
+	}
+	return []string{"-crf", strconv.Itoa(crf)}
+}
+
+// videoCRF is the CRF of the quality preset, adjusted to the output
+// resolution with AutoQuality, or the one set by an attempt to fit MaxSize.
+func videoCRF(opts *Options, src *ProbeInfo) int {
+	if opts.crf > 0 {
+		return opts.crf
+	}
+	crf := crfMap[opts.Quality]
+	if opts.AutoQuality {
+		crf += crfResolutionOffset(outputHeight(opts, src))
+	}
+	return crf
+}
+
+// crfOffsets nudges the CRF by output height for AutoQuality: artifacts
+// are smaller relative to the picture at high resolutions, so they
+// tolerate a higher CRF, while small outputs need a lower one to look as
+// good. Heights between entries use the next lower one.
+var crfOffsets = []struct {
+	height int
+	offset int
+}{
+	{2160, 4},
+	{1440, 2},
+	{1080, 0},
+	{720, -1},
+	{480, -2},
+	{0, -3},
+}
+
+func crfResolutionOffset(height int) int {
+	for _, o := range crfOffsets {
+		if height >= o.height {
+			return o.offset
+		}
+	}
+	return 0
+}
+
+// ladderBitrate picks the smallest ladder rung that covers height, so a
+// 900p output gets the 1080p bitrate.
+func ladderBitrate(height int, q Quality) string {
+	heights := make([]int, 0, len(bitrateLadder))
+	for h := range bitrateLadder {
+		heights = append(heights, h)
+	}
+	sort.Ints(heights)
+
+	for _, h := range heights {
+		if height <= h {
+			return bitrateLadder[h][q]
+		}
+	}
+	return bitrateLadder[heights[len(heights)-1]][q]
+}
+
+// outputHeight returns the height the output will have: the requested
+// resolution if any, the one MaxResolution caps it to, otherwise the
+// source height, falling back to 1080.
+func outputHeight(opts *Options, src *ProbeInfo) int {
+	if m := resolutionHeightRegex.FindStringSubmatch(opts.Resolution); m != nil {
+		h, _ := strconv.Atoi(m[1])
+		return h
+	}
+	if _, h, ok := cappedSize(opts, src); ok {
+		return h
+	}
+	if v := src.VideoStream(); v != nil && v.Height > 0 {
+		return v.Height
+	}
+	return 1080
+}
@@ -0,0 +1,62 @@
+package converter
+
+import "slices"
+
+// containerVideoCodecs lists the source video codecs (as named by ffprobe)
+// each container can hold as they are.
+var containerVideoCodecs = map[string][]string{
+	"mp4":  {"h264", "hevc", "vp9", "av1", "mpeg4"},
+	"mov":  {"h264", "hevc", "prores", "mpeg4", "mjpeg"},
+	"mkv":  {"h264", "hevc", "vp8", "vp9", "av1", "mpeg4", "mpeg2video", "prores", "mjpeg", "ffv1"},
+	"webm": {"vp8", "vp9", "av1"},
+	"avi":  {"h264", "mpeg4", "mjpeg"},
+}
+
+// canRemux reports whether the conversion is only a container change: no
+// encoding option is set, the output container holds the source's video
+// codec and its audio can be copied too. ExtraArgs may ask for anything,
+// so they always re-encode.
+func canRemux(opts *Options, src *ProbeInfo) bool {
+	if opts.ForceReencode || src == nil || needsReencode(opts) {
+		return false
+	}
+	if _, _, capped := cappedSize(opts, src); capped {
+		return false
+	}
+	if opts.Audio != "" || opts.ReplaceAudio != "" || opts.BackgroundAudio != "" ||
+		opts.AspectRatio != "" || opts.Start > 0 || len(opts.ExtraArgs) > 0 {
+		return false
+	}
+	v := src.VideoStream()
+	if v == nil || !slices.Contains(containerVideoCodecs[opts.Format], v.Codec) {
+		return false
+	}
+	return len(src.StreamsOfType("audio")) == 0 || canCopyAudio(opts, src)
+}
+
+// remuxArgs copies the video and audio into the new container. Apple
+// players only recognize HEVC in mp4/mov under the hvc1 tag.
+func remuxArgs(opts *Options, src *ProbeInfo) []string {
+	args := []string{"-c:v", "copy"}
+	if src.VideoStream().Codec == "hevc" && fastStartFormats[opts.Format] {
+		args = append(args, "-tag:v", "hvc1")
+	}
+	return append(args, "-c:a", "copy")
+}
+
+func WillRemux(opts *Options) bool {
+	return defaultConverter.WillRemux(opts)
+}
+
+// WillRemux reports whether converting opts only changes the container,
+// copying the streams instead of re-encoding them. It probes the input.
+func (c *Converter) WillRemux(opts *Options) bool {
+	if len(opts.Renditions) > 0 {
+		return false
+	}
+	src, err := c.probeInput(opts)
+	if err != nil {
+		return false
+	}
+	return canRemux(opts, src)
+}
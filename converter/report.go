@@ -0,0 +1,88 @@
+package converter
+
+import (
+	"os"
+	"time"
+)
+
+type Report struct {
+	Input    string  `json:"input"`
+	Output   string  `json:"output"`
+	Duration float64 `json:"duration"`
+	Elapsed  float64 `json:"elapsed"`
+	Size     int64   `json:"size"`
+
+	// Codec is the video encoder the options select, e.g. libx264, on
+	// success and failure alike.
+	Codec   string `json:"codec"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// NewReport summarizes a finished conversion. Duration comes from probing
+// the output, so it reflects what was actually written.
+func NewReport(opts *Options, elapsed time.Duration, convErr error) *Report {
+	return defaultConverter.NewReport(opts, elapsed, convErr)
+}
+
+func (c *Converter) NewReport(opts *Options, elapsed time.Duration, convErr error) *Report {
+	r := &Report{
+		Input:   opts.Input,
+		Output:  opts.Output,
+		Elapsed: elapsed.Seconds(),
+		Codec:   videoCodec(opts),
+		Success: convErr == nil,
+	}
+	if convErr != nil {
+		r.Error = convErr.Error()
+		return r
+	}
+
+	if info, err := os.Stat(opts.Output); err == nil {
+		r.Size = info.Size()
+	}
+	if probe, err := c.Probe(opts.Output); err == nil {
+		r.Duration = probe.Duration.Seconds()
+	}
+	return r
+}
+
+type OutputStats struct {
+	BitRate int64
+	Width   int
+	Height  int
+
+	// CompressionRatio is the input size divided by the output size.
+	CompressionRatio float64
+}
+
+func Stats(opts *Options) (*OutputStats, error) {
+	return defaultConverter.Stats(opts)
+}
+
+// Stats probes a finished conversion's output to report what the encoder
+// actually produced.
+func (c *Converter) Stats(opts *Options) (*OutputStats, error) {
+	out, err := c.Probe(opts.Output)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &OutputStats{BitRate: out.BitRate}
+	if v := out.VideoStream(); v != nil {
+		stats.Width = v.Width
+		stats.Height = v.Height
+	}
+
+	// After an in-place conversion the input is gone, so there is nothing
+	// left to compare against.
+	if opts.InPlace {
+		return stats, nil
+	}
+
+	in, err := os.Stat(opts.Input)
+	if err == nil && out.Size > 0 {
+		stats.CompressionRatio = float64(in.Size()) / float64(out.Size)
+	}
+	return stats, nil
+}
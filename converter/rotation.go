@@ -0,0 +1,21 @@
+package converter
+
+// rotationFilters turns a video clockwise by the given angle.
+var rotationFilters = map[int]string{
+	90:  "transpose=clock",
+	180: "hflip,vflip",
+	270: "transpose=cclock",
+}
+
+// rotationFilter returns the filter that bakes the source's rotation into
+// the pixels, or "" when RotateAuto is off or the video is upright.
+func rotationFilter(opts *Options, src *ProbeInfo) string {
+	if !opts.RotateAuto {
+		return ""
+	}
+	v := src.VideoStream()
+	if v == nil {
+		return ""
+	}
+	return rotationFilters[v.Rotation]
+}
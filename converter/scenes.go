@@ -0,0 +1,86 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSceneThreshold is the scene change score (0-1) above which a
+// frame starts a new scene. Lower finds more, subtler cuts.
+const DefaultSceneThreshold = 0.4
+
+var sceneShowinfoRegex = regexp.MustCompile(`Parsed_showinfo_\d+ .*pts_time:\s*([\d.]+)`)
+
+// DetectScenes returns the timestamps of the input's scene changes, where
+// ffmpeg's scene score of a frame exceeds threshold (0-1, default 0.4).
+func DetectScenes(input string, threshold float64) ([]time.Duration, error) {
+	return defaultConverter.DetectScenes(input, threshold)
+}
+
+func (c *Converter) DetectScenes(input string, threshold float64) ([]time.Duration, error) {
+	if threshold == 0 {
+		threshold = DefaultSceneThreshold
+	}
+	if err := validateSceneThreshold(threshold); err != nil {
+		return nil, err
+	}
+	return c.detectScenes(context.Background(), input, threshold, 0, 0, 0)
+}
+
+// detectScenes runs select over limit of the input from start (all of it
+// if zero), at niceness nice, and parses the showinfo line of every frame
+// it lets through. Timestamps are relative to start, as in the converted
+// output.
+func (c *Converter) detectScenes(ctx context.Context, input string, threshold float64, start, limit time.Duration, nice int) ([]time.Duration, error) {
+	args := []string{"-hide_banner"}
+	if start > 0 {
+		args = append(args, "-ss", formatSeconds(start))
+	}
+	args = append(args, "-i", input)
+	if limit > 0 {
+		args = append(args, "-t", formatSeconds(limit))
+	}
+	filter := fmt.Sprintf("select='gt(scene,%s)',showinfo", strconv.FormatFloat(threshold, 'f', -1, 64))
+	args = append(args, "-map", "0:v:0", "-vf", filter, "-an", "-f", "null", "-")
+
+	out, err := c.combinedOutputNiced(exec.CommandContext(ctx, c.ffmpeg(), args...), nice)
+	if err != nil {
+		return nil, fmt.Errorf("scene detection failed: %w\n%s", err, lastLines(string(out), 5))
+	}
+	return parseScenes(string(out)), nil
+}
+
+func parseScenes(log string) []time.Duration {
+	var scenes []time.Duration
+	for _, line := range strings.Split(log, "\n") {
+		if m := sceneShowinfoRegex.FindStringSubmatch(line); m != nil {
+			scenes = append(scenes, parseSeconds(m[1]))
+		}
+	}
+	return scenes
+}
+
+func validateSceneThreshold(threshold float64) error {
+	if threshold <= 0 || threshold >= 1 {
+		return fmt.Errorf("invalid scene threshold: %g (must be between 0 and 1, e.g. 0.4)", threshold)
+	}
+	return nil
+}
+
+// sceneKeyframeArgs forces a keyframe at each scene change found before
+// converting with SceneDetect.
+func sceneKeyframeArgs(cuts []time.Duration) []string {
+	if len(cuts) == 0 {
+		return nil
+	}
+	times := make([]string, len(cuts))
+	for i, t := range cuts {
+		times[i] = formatSeconds(t)
+	}
+	return []string{"-force_key_frames", strings.Join(times, ",")}
+}
@@ -0,0 +1,80 @@
+package converter
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Silence is a quiet stretch of the input found by DetectSilence.
+type Silence struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// Midpoint is where a split at this silence cuts.
+func (s Silence) Midpoint() time.Duration {
+	return s.Start + (s.End-s.Start)/2
+}
+
+const (
+	defaultSilenceThreshold = -30.0
+	defaultMinSilence       = 2 * time.Second
+)
+
+var (
+	silenceStartRegex = regexp.MustCompile(`silence_start: (-?[\d.]+)`)
+	silenceEndRegex   = regexp.MustCompile(`silence_end: ([\d.]+)`)
+)
+
+// DetectSilence runs ffmpeg's silencedetect filter over the input's audio
+// and returns every stretch quieter than threshold dB lasting at least
+// minDuration.
+func DetectSilence(input string, threshold float64, minDuration time.Duration) ([]Silence, error) {
+	return defaultConverter.DetectSilence(input, threshold, minDuration)
+}
+
+func (c *Converter) DetectSilence(input string, threshold float64, minDuration time.Duration) ([]Silence, error) {
+	filter := fmt.Sprintf("silencedetect=noise=%sdB:d=%s",
+		strconv.FormatFloat(threshold, 'f', -1, 64), formatSeconds(minDuration))
+	cmd := exec.Command(c.ffmpeg(),
+		"-hide_banner",
+		"-i", input,
+		"-map", "0:a:0",
+		"-af", filter,
+		"-vn",
+		"-f", "null", "-",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("silence detection failed: %w\n%s", err, lastLines(string(out), 5))
+	}
+	return parseSilences(string(out)), nil
+}
+
+// parseSilences pairs silencedetect's silence_start and silence_end lines.
+// A silence still open at the end of the input is ignored: there is
+// nothing after it to split off.
+func parseSilences(log string) []Silence {
+	var silences []Silence
+	var start time.Duration
+	open := false
+	for _, line := range strings.Split(log, "\n") {
+		if m := silenceStartRegex.FindStringSubmatch(line); m != nil {
+			start = parseSeconds(m[1])
+			open = true
+		} else if m := silenceEndRegex.FindStringSubmatch(line); m != nil && open {
+			silences = append(silences, Silence{Start: max(start, 0), End: parseSeconds(m[1])})
+			open = false
+		}
+	}
+	return silences
+}
+
+func parseSeconds(s string) time.Duration {
+	seconds, _ := strconv.ParseFloat(s, 64)
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,184 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type SplitOptions struct {
+	Input       string
+	SegmentTime time.Duration
+	Parts       int
+
+	// BySilence cuts in the middle of each pause quieter than
+	// SilenceThreshold dB (default -30) lasting at least MinSilence
+	// (default 2s), e.g. between the topics of a lecture.
+	BySilence        bool
+	SilenceThreshold float64
+	MinSilence       time.Duration
+}
+
+func Split(opts *SplitOptions, onProgress ProgressFunc) ([]string, error) {
+	return defaultConverter.Split(opts, onProgress)
+}
+
+// ValidateSplitOptions checks opts before running. Every error it returns
+// matches ErrInvalidOptions.
+func ValidateSplitOptions(opts *SplitOptions) error {
+	return invalidOptions(validateSplitOptions(opts))
+}
+
+func validateSplitOptions(opts *SplitOptions) error {
+	if _, err := os.Stat(opts.Input); os.IsNotExist(err) {
+		return fmt.Errorf("input file does not exist: %s", opts.Input)
+	}
+	modes := 0
+	for _, set := range []bool{opts.SegmentTime != 0, opts.Parts != 0, opts.BySilence} {
+		if set {
+			modes++
+		}
+	}
+	if modes == 0 {
+		return fmt.Errorf("specify one of --segment-time, --parts or --segment-by-silence")
+	}
+	if modes > 1 {
+		return fmt.Errorf("--segment-time, --parts and --segment-by-silence cannot be used together")
+	}
+	if opts.SegmentTime < 0 {
+		return fmt.Errorf("invalid segment time: %s (must be positive)", opts.SegmentTime)
+	}
+	if opts.Parts < 0 {
+		return fmt.Errorf("invalid number of parts: %d (must be positive)", opts.Parts)
+	}
+	if opts.SilenceThreshold > 0 {
+		return fmt.Errorf("invalid silence threshold: %gdB (must be 0 or below, e.g. -30)", opts.SilenceThreshold)
+	}
+	if opts.MinSilence < 0 {
+		return fmt.Errorf("invalid minimum silence: %s (must be positive)", opts.MinSilence)
+	}
+	return nil
+}
+
+// Split cuts the input into numbered segments next to it (name_001.ext,
+// name_002.ext, ...) without re-encoding, so cut points snap to keyframes.
+// With BySilence the input is first scanned for pauses to cut at.
+// It returns the paths of the segments written.
+func (c *Converter) Split(opts *SplitOptions, onProgress ProgressFunc) ([]string, error) {
+	if err := ValidateSplitOptions(opts); err != nil {
+		return nil, err
+	}
+
+	var total time.Duration
+	src, err := c.Probe(opts.Input)
+	if err == nil {
+		total = src.Duration
+	}
+
+	var cuts []string
+	if opts.BySilence {
+		cuts, err = c.silenceCuts(opts)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		segment := opts.SegmentTime
+		if opts.Parts > 0 {
+			if total <= 0 {
+				return nil, fmt.Errorf("cannot split into parts: unable to determine duration of %s", opts.Input)
+			}
+			segment = total / time.Duration(opts.Parts)
+		}
+		cuts = []string{"-segment_time", formatSeconds(segment)}
+	}
+
+	temps := &cleanup{}
+	defer temps.removeAll()
+
+	// ffmpeg lists the segments it writes, so segments left by an earlier
+	// split aren't mistaken for this one's.
+	list, err := temps.createTemp("", "fk-converter-segments-*.txt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create segment list: %w", err)
+	}
+	list.Close()
+
+	// A literal % in the name must not be read as a pattern.
+	base := strings.ReplaceAll(trimExtension(opts.Input), "%", "%%")
+
+	// Segments are named for what the input really holds, since the
+	// segment muxer picks the container from their extension.
+	ext := inputExtension(opts.Input, src)
+	if ext != "" {
+		ext = "." + strings.ReplaceAll(ext, "%", "%%")
+	}
+	pattern := base + "_%03d" + ext
+
+	args := []string{
+		"-i", opts.Input, "-y", "-progress", "pipe:2", "-nostats",
+		"-map", "0", "-c", "copy",
+		"-f", "segment",
+	}
+	args = append(args, cuts...)
+	args = append(args,
+		"-segment_start_number", "1",
+		"-reset_timestamps", "1",
+		"-segment_list", list.Name(),
+		"-segment_list_type", "flat",
+		pattern,
+	)
+
+	if err := c.runFFmpeg(context.Background(), args, progressTotal{duration: total}, progressReporter(onProgress, nil)); err != nil {
+		return nil, fmt.Errorf("ffmpeg split failed: %w", err)
+	}
+
+	return readSegmentList(list.Name(), filepath.Dir(opts.Input))
+}
+
+// readSegmentList returns the segments in a flat -segment_list, whose
+// entries are file names relative to dir.
+func readSegmentList(path, dir string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read segment list: %w", err)
+	}
+	var segments []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			segments = append(segments, filepath.Join(dir, line))
+		}
+	}
+	return segments, nil
+}
+
+// silenceCuts returns the segment muxer options cutting at the middle of
+// each detected silence.
+func (c *Converter) silenceCuts(opts *SplitOptions) ([]string, error) {
+	threshold := opts.SilenceThreshold
+	if threshold == 0 {
+		threshold = defaultSilenceThreshold
+	}
+	minSilence := opts.MinSilence
+	if minSilence == 0 {
+		minSilence = defaultMinSilence
+	}
+
+	silences, err := c.DetectSilence(opts.Input, threshold, minSilence)
+	if err != nil {
+		return nil, err
+	}
+
+	var times []string
+	for _, s := range silences {
+		if s.Start > 0 {
+			times = append(times, formatSeconds(s.Midpoint()))
+		}
+	}
+	if len(times) == 0 {
+		return nil, fmt.Errorf("no silence of at least %s below %gdB found in %s", minSilence, threshold, opts.Input)
+	}
+	return []string{"-segment_times", strings.Join(times, ",")}, nil
+}
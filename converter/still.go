@@ -0,0 +1,56 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+)
+
+// isStillImage reports whether the input is a single image to be turned
+// into a video with Audio as its soundtrack.
+func isStillImage(opts *Options) bool {
+	return imageFormats[getExtension(opts.Input)]
+}
+
+func validateStillImage(opts *Options) error {
+	if !isStillImage(opts) {
+		return fmt.Errorf("--audio requires an image input (to swap a video's soundtrack use --replace-audio)")
+	}
+	if _, err := os.Stat(opts.Audio); os.IsNotExist(err) {
+		return fmt.Errorf("audio file does not exist: %s", opts.Audio)
+	}
+	switch {
+	case opts.ReplaceAudio != "":
+		return fmt.Errorf("--audio cannot be combined with --replace-audio")
+	case opts.BackgroundAudio != "":
+		return fmt.Errorf("--audio cannot be combined with --background-audio")
+	case opts.MapAll:
+		return fmt.Errorf("--audio cannot be combined with --map-all")
+	case opts.Loop > 0:
+		return fmt.Errorf("--audio cannot be combined with --loop")
+	}
+	return nil
+}
+
+// stillImageArgs tunes the encoder for a static picture, unless another
+// tune was asked for, and uses the widely supported yuv420p, since images
+// are often 4:4:4 or RGB. Lossless output keeps the image's own format.
+func stillImageArgs(opts *Options, codec string) []string {
+	var args []string
+	if opts.Quality != QualityLossless {
+		args = append(args, "-pix_fmt", "yuv420p")
+	}
+	if codec == "libx264" && opts.Tune == "" {
+		args = append(args, "-tune", "stillimage")
+	}
+	return args
+}
+
+// stillImageTotal measures progress against the soundtrack, which sets the
+// length of the video.
+func (c *Converter) stillImageTotal(opts *Options) progressTotal {
+	duration, err := c.probeDuration(opts.Audio)
+	if err != nil {
+		return progressTotal{}
+	}
+	return progressTotal{duration: duration}
+}
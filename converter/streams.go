@@ -0,0 +1,84 @@
+package converter
+
+import (
+	"fmt"
+	"slices"
+)
+
+// streamTypes lists ffprobe stream types with their ffmpeg stream
+// specifier, in the order MapAll maps them.
+var streamTypes = []struct {
+	name      string
+	specifier string
+}{
+	{"video", "v"},
+	{"audio", "a"},
+	{"subtitle", "s"},
+	{"data", "d"},
+	{"attachment", "t"},
+}
+
+// containerStreamTypes lists the stream types MapAll carries into each
+// container. Streams of any other type are dropped with a warning.
+var containerStreamTypes = map[string][]string{
+	"mkv":  {"video", "audio", "subtitle", "data", "attachment"},
+	"mp4":  {"video", "audio"},
+	"mov":  {"video", "audio"},
+	"webm": {"video", "audio"},
+	"avi":  {"video", "audio"},
+}
+
+// needsReencode reports whether opts asks for anything a stream copy can't
+// do. The default medium quality doesn't count, so MapAll copies unless the
+// user explicitly changes the encoding.
+func needsReencode(opts *Options) bool {
+	return opts.Codec != "" || opts.AutoQuality ||
+		(opts.Quality != "" && opts.Quality != QualityMedium) ||
+		opts.RateControl == RateControlBitrate ||
+		opts.VideoBitrate != "" ||
+		opts.Resolution != "" ||
+		opts.Profile != "" || opts.Level != "" ||
+		opts.Deinterlace || opts.DetectInterlace || opts.Interlace != "" ||
+		opts.CustomVideoFilter != "" || opts.CustomAudioFilter != "" ||
+		opts.KeyframeInterval > 0 || opts.KeyframeEvery > 0 || opts.SceneDetect > 0 ||
+		opts.SampleRate > 0 || opts.AudioBitrate != "" ||
+		opts.ConstantFrameRate || opts.DropDuplicates || opts.SmoothFPS > 0 ||
+		opts.AudioDelay != 0 || opts.DownmixStereo || len(opts.Mute) > 0 ||
+		opts.ChannelMap != "" || opts.TargetLUFS != 0 || opts.MaxSize > 0 ||
+		opts.ColorSpace != "" || opts.RotateAuto ||
+		opts.X264Params != "" || opts.X265Params != "" || opts.Tune != ""
+}
+
+// mapAllArgs maps every input stream the output container can hold. When
+// re-encoding, streams other than video and audio are still copied.
+func mapAllArgs(opts *Options) []string {
+	var args []string
+	allowed := containerStreamTypes[opts.Format]
+	for _, t := range streamTypes {
+		if slices.Contains(allowed, t.name) {
+			args = append(args, "-map", "0:"+t.specifier+"?")
+		}
+	}
+	if !needsReencode(opts) {
+		return append(args, "-c", "copy")
+	}
+	for _, t := range streamTypes[2:] {
+		if slices.Contains(allowed, t.name) {
+			args = append(args, "-c:"+t.specifier, "copy")
+		}
+	}
+	return args
+}
+
+// droppedStreams describes the input streams MapAll can't carry into the
+// output container, e.g. "2 subtitle".
+func droppedStreams(opts *Options, src *ProbeInfo) []string {
+	var dropped []string
+	allowed := containerStreamTypes[opts.Format]
+	for _, t := range streamTypes {
+		if n := len(src.StreamsOfType(t.name)); n > 0 && !slices.Contains(allowed, t.name) {
+			dropped = append(dropped, fmt.Sprintf("%d %s", n, t.name))
+		}
+	}
+	return dropped
+}
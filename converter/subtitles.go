@@ -0,0 +1,144 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+)
+
+var subtitleFormats = map[string]string{
+	"srt": "srt",
+	"vtt": "webvtt",
+	"ass": "ass",
+}
+
+var bitmapSubtitleCodecs = map[string]bool{
+	"hdmv_pgs_subtitle": true,
+	"dvd_subtitle":      true,
+	"dvb_subtitle":      true,
+}
+
+func ExtractSubtitle(input string, track int, output string) error {
+	return defaultConverter.ExtractSubtitle(input, track, output)
+}
+
+// ExtractSubtitle writes the track-th subtitle stream of input to output,
+// converting it to the text format implied by output's extension.
+func (c *Converter) ExtractSubtitle(input string, track int, output string) error {
+	ext := getExtension(output)
+	codec, ok := subtitleFormats[ext]
+	if !ok {
+		return fmt.Errorf("unsupported subtitle format: %q (supported: srt, vtt, ass)", ext)
+	}
+
+	info, err := c.Probe(input)
+	if err != nil {
+		return err
+	}
+
+	tracks := info.StreamsOfType("subtitle")
+	if len(tracks) == 0 {
+		return fmt.Errorf("%s has no subtitle tracks", input)
+	}
+	if track < 0 || track >= len(tracks) {
+		return fmt.Errorf("subtitle track %d does not exist (available: 0-%d)", track, len(tracks)-1)
+	}
+	if bitmapSubtitleCodecs[tracks[track].Codec] {
+		return fmt.Errorf("subtitle track %d is image-based (%s) and cannot be converted to %s", track, tracks[track].Codec, ext)
+	}
+
+	args := []string{"-i", input, "-y", "-map", fmt.Sprintf("0:s:%d", track), "-c:s", codec, output}
+	out, err := exec.Command(c.ffmpeg(), args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("subtitle extraction failed: %w\n%s", err, lastLines(string(out), 5))
+	}
+	return nil
+}
+
+// SubtitleTrack is an external subtitle file muxed into the output as its
+// own track, tagged with Language (ISO 639-2) unless that is empty.
+type SubtitleTrack struct {
+	Path     string
+	Language string
+}
+
+// subtitleMuxCodecs is the subtitle codec used for added tracks in each
+// container that can hold several. Matroska stores srt, vtt and ass as
+// they are; mp4 only holds mov_text.
+var subtitleMuxCodecs = map[string]string{
+	"mkv": "copy",
+	"mp4": "mov_text",
+}
+
+func validateSubtitleTracks(opts *Options) error {
+	if _, ok := subtitleMuxCodecs[opts.Format]; !ok {
+		return fmt.Errorf("--subtitles is not supported for %s output (supported: mkv, mp4)", opts.Format)
+	}
+	for _, track := range opts.Subtitles {
+		if _, err := os.Stat(track.Path); os.IsNotExist(err) {
+			return fmt.Errorf("subtitle file does not exist: %s", track.Path)
+		}
+		if ext := getExtension(track.Path); subtitleFormats[ext] == "" && ext != "ssa" {
+			return fmt.Errorf("unsupported subtitle file: %s (supported: srt, vtt, ass, ssa)", track.Path)
+		}
+		if track.Language != "" {
+			if err := validateLanguages("--subtitles", []string{track.Language}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// subtitleInputIndex is the ffmpeg input index of the first subtitle file,
+// which follow every other input.
+func subtitleInputIndex(opts *Options) int {
+	if opts.Chapters != "" {
+		return chapterInputIndex(opts) + 1
+	}
+	return chapterInputIndex(opts)
+}
+
+// subtitleInputArgs adds the subtitle files as inputs. A fast seek only
+// applies to the input it precedes, so it is repeated for each of them to
+// keep them in sync with the video.
+func subtitleInputArgs(opts *Options) []string {
+	var args []string
+	for _, track := range opts.Subtitles {
+		if opts.Start > 0 && !opts.AccurateSeek {
+			args = append(args, "-ss", formatSeconds(opts.Start))
+		}
+		args = append(args, "-i", track.Path)
+	}
+	return args
+}
+
+// subtitleTrackArgs maps each subtitle file to a new track after the
+// source's own subtitles, if any are kept. ffmpeg stops picking streams by
+// itself once one is mapped, so unless another option already maps them,
+// the video and audio are mapped too.
+func subtitleTrackArgs(opts *Options, src *ProbeInfo) []string {
+	if len(opts.Subtitles) == 0 {
+		return nil
+	}
+	var args []string
+	if !opts.MapAll && opts.Audio == "" && opts.ReplaceAudio == "" &&
+		opts.BackgroundAudio == "" && opts.DefaultAudioTrack == nil {
+		args = append(args, "-map", "0:v:0", "-map", "0:a:0?")
+	}
+
+	first := 0
+	if opts.MapAll && slices.Contains(containerStreamTypes[opts.Format], "subtitle") && src != nil {
+		first = len(src.StreamsOfType("subtitle"))
+	}
+	input := subtitleInputIndex(opts)
+	for i, track := range opts.Subtitles {
+		args = append(args, "-map", strconv.Itoa(input+i)+":s:0")
+		if track.Language != "" {
+			args = append(args, "-metadata:s:s:"+strconv.Itoa(first+i), "language="+track.Language)
+		}
+	}
+	return append(args, "-c:s", subtitleMuxCodecs[opts.Format])
+}
@@ -0,0 +1,35 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// TimestampSource is where PreserveTimestamps takes the output's
+// modification time from.
+type TimestampSource string
+
+const (
+	TimestampFile  TimestampSource = "file"
+	TimestampMedia TimestampSource = "media"
+)
+
+// sourceTimestamp returns the time PreserveTimestamps gives the output, or
+// the zero time if it is off.
+func sourceTimestamp(opts *Options, src *ProbeInfo) (time.Time, error) {
+	switch opts.PreserveTimestamps {
+	case TimestampFile:
+		info, err := os.Stat(opts.Input)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("cannot read the modification time of %s: %w", opts.Input, err)
+		}
+		return info.ModTime(), nil
+	case TimestampMedia:
+		if src == nil || src.CreationTime.IsZero() {
+			return time.Time{}, fmt.Errorf("--preserve-timestamps media: %s has no creation_time tag (use --preserve-timestamps file)", opts.Input)
+		}
+		return src.CreationTime, nil
+	}
+	return time.Time{}, nil
+}
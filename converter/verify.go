@@ -0,0 +1,48 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// verifyTolerance is how much shorter than expected an output may be before
+// it counts as truncated, as a fraction of the expected duration. Short
+// outputs get at least minVerifyTolerance to absorb container rounding.
+const (
+	verifyTolerance    = 0.01
+	minVerifyTolerance = time.Second
+)
+
+// verifyOutput checks that the file at opts.Output is readable and, when the
+// source duration is known, not significantly shorter than expected. With
+// VerifyFull it also decodes the whole file, catching corruption that
+// ffprobe alone can't see.
+func (c *Converter) verifyOutput(ctx context.Context, opts *Options, src *ProbeInfo) error {
+	out, err := c.Probe(opts.Output)
+	if err != nil {
+		return fmt.Errorf("output verification failed: %w", err)
+	}
+
+	// With a replaced soundtrack the output legitimately ends early, at the
+	// end of the shorter stream.
+	expected := conversionTotal(opts, src).duration
+	if expected > 0 && opts.ReplaceAudio == "" {
+		tolerance := max(time.Duration(float64(expected)*verifyTolerance), minVerifyTolerance)
+		if out.Duration < expected-tolerance {
+			return fmt.Errorf("output verification failed: %s is %s long, expected %s (the encode may be truncated)",
+				opts.Output, out.Duration.Round(time.Millisecond), expected.Round(time.Millisecond))
+		}
+	}
+
+	if opts.VerifyFull {
+		cmd := exec.CommandContext(ctx, c.ffmpeg(), "-v", "error", "-i", opts.Output, "-f", "null", "-")
+		msg, err := c.combinedOutputNiced(cmd, opts.Nice)
+		if err != nil || strings.TrimSpace(string(msg)) != "" {
+			return fmt.Errorf("output verification failed: decoding %s reported errors:\n%s", opts.Output, lastLines(string(msg), 5))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package converter
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// versionCache holds the ffmpeg version, queried once per Converter.
+type versionCache struct {
+	once    sync.Once
+	version string
+	err     error
+}
+
+func FFmpegVersion() (string, error) {
+	return defaultConverter.FFmpegVersion()
+}
+
+func RequireFFmpegVersion(min string) error {
+	return defaultConverter.RequireFFmpegVersion(min)
+}
+
+// FFmpegVersion returns the version ffmpeg reports, e.g. "6.1.1",
+// "4.4.2-0ubuntu0.22.04.1" or "N-113000-g1234abcd" for a git build. It runs
+// ffmpeg only the first time.
+func (c *Converter) FFmpegVersion() (string, error) {
+	c.version.once.Do(func() {
+		c.version.version, c.version.err = c.queryVersion()
+	})
+	return c.version.version, c.version.err
+}
+
+func (c *Converter) queryVersion() (string, error) {
+	out, err := exec.Command(c.ffmpeg(), "-hide_banner", "-version").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get ffmpeg version: %w", err)
+	}
+	first, _, _ := strings.Cut(string(out), "\n")
+	fields := strings.Fields(first)
+	if len(fields) < 3 || fields[1] != "version" {
+		return "", fmt.Errorf("unrecognized ffmpeg version output: %q", first)
+	}
+	return fields[2], nil
+}
+
+// RequireFFmpegVersion returns an ErrFFmpegTooOld error if ffmpeg is older
+// than min, e.g. "5.0". Git builds and versions that can't be read are
+// assumed to be recent enough.
+func (c *Converter) RequireFFmpegVersion(min string) error {
+	version, err := c.FFmpegVersion()
+	if err != nil {
+		return nil
+	}
+	have, ok := parseVersion(version)
+	want, _ := parseVersion(min)
+	if !ok || compareVersions(have, want) >= 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: requires ffmpeg >= %s, found %s", ErrFFmpegTooOld, min, version)
+}
+
+// parseVersion reads the leading dotted numbers of a release version,
+// ignoring an "n" prefix and distribution suffixes: "n4.4.2-0ubuntu1"
+// gives [4 4 2].
+func parseVersion(s string) ([]int, bool) {
+	s = strings.TrimPrefix(s, "n")
+	end := strings.IndexFunc(s, func(r rune) bool { return r != '.' && (r < '0' || r > '9') })
+	if end >= 0 {
+		s = s[:end]
+	}
+	var parts []int
+	for _, field := range strings.Split(strings.Trim(s, "."), ".") {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, false
+		}
+		parts = append(parts, n)
+	}
+	return parts, true
+}
+
+// compareVersions compares dotted versions, treating missing parts as 0.
+func compareVersions(a, b []int) int {
+	for i := range max(len(a), len(b)) {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			return x - y
+		}
+	}
+	return 0
+}
@@ -0,0 +1,103 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+var standardSampleRates = map[int]bool{
+	8000:  true,
+	16000: true,
+	22050: true,
+	44100: true,
+	48000: true,
+}
+
+func (c *Converter) warn(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if c.Warn != nil {
+		c.Warn(msg)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %s\n", msg)
+}
+
+// checkWarnings reports settings that are allowed but probably not what the
+// user wants. It runs once per conversion, after the input was probed; src
+// may be nil.
+func (c *Converter) checkWarnings(opts *Options, src *ProbeInfo) {
+	if !src.MatchesExtension(opts.Input) {
+		c.warn("%s is really %s, not .%s", opts.Input, src.Container(), getExtension(opts.Input))
+	}
+
+	if opts.MaxDuration > 0 && src != nil && opts.Audio == "" {
+		if d := requestedTotal(opts, src).duration; d > opts.MaxDuration {
+			c.warn("output would run %s, longer than --max-duration; cutting it at %s", d.Round(time.Second), opts.MaxDuration)
+		}
+	}
+
+	if opts.SampleRate > 0 && !standardSampleRates[opts.SampleRate] {
+		c.warn("%d Hz is not a standard sample rate (8000, 16000, 22050, 44100, 48000); some players may not support it", opts.SampleRate)
+	}
+
+	if opts.Quality == QualityLossless {
+		if _, ok := losslessAudioCodecs[opts.Format]; !ok {
+			c.warn("%s has no lossless audio codec; audio is encoded lossy", opts.Format)
+		}
+		if opts.ExtractAudio != "" && extractAudioFormat(opts).bitrate != "" {
+			c.warn("%s is a lossy audio format; use flac, wav or m4a for lossless extraction", opts.ExtractAudio)
+		}
+		if v := src.VideoStream(); v != nil && v.PixelFormat != "" {
+			if _, ok := losslessPixelFormat(videoCodec(opts), src); !ok {
+				c.warn("%s can't store %s pixels losslessly; colors are converted", videoCodec(opts), v.PixelFormat)
+			}
+		}
+	}
+
+	if opts.SmoothFPS > 0 {
+		c.warn("--smooth-fps interpolates motion on the CPU and is very slow, often far below realtime")
+	}
+
+	if opts.DownmixStereo {
+		if n := audioChannels(src); n == 1 || n == 2 {
+			c.warn("--downmix-stereo ignored: the audio already has %d channel(s)", n)
+		}
+	}
+
+	codec := videoCodec(opts)
+	if opts.X264Params != "" && codec != "libx264" {
+		c.warn("--x264-params ignored: the video is encoded with %s", codec)
+	}
+	if opts.X265Params != "" && codec != "libx265" {
+		c.warn("--x265-params ignored: the video is encoded with %s", codec)
+	}
+
+	if opts.MapAll && src != nil {
+		if dropped := droppedStreams(opts, src); len(dropped) > 0 {
+			c.warn("%s can't hold every stream: dropping %s stream(s)", opts.Format, strings.Join(dropped, ", "))
+		}
+	}
+
+	if opts.Deterministic && opts.HWDecode != "" {
+		c.warn("--hw-decode output can differ between GPUs and drivers; --deterministic only holds on the same machine")
+	}
+
+	if len(opts.SubtitleLanguages) > 0 && len(opts.Subtitles) == 0 && !(opts.MapAll && slices.Contains(containerStreamTypes[opts.Format], "subtitle")) {
+		c.warn("--subtitle-lang has no effect: subtitles are only kept with --map-all and mkv output, or added with --subtitles")
+	}
+
+	if opts.ScaleAlgorithm != "" && opts.Resolution == "" && opts.MaxResolution == "" {
+		c.warn("--scale-algorithm has no effect without --resolution or --max-resolution")
+	}
+
+	if opts.ColorSpace != "" && isHDR(src) {
+		c.warn("%s is HDR; --color-space converts without tone mapping, so colors will look washed out", opts.Input)
+	}
+
+	if src != nil && src.IsVFR() && !opts.ConstantFrameRate && !opts.DropDuplicates {
+		c.warn("%s has a variable frame rate; use --cfr if audio and video drift out of sync", opts.Input)
+	}
+}
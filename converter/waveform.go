@@ -0,0 +1,100 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// WaveformOptions describes a waveform image of the input's audio.
+type WaveformOptions struct {
+	Input  string
+	Output string
+	Width  int
+	Height int
+
+	// Color is an ffmpeg color: a name (e.g. white) or #RRGGBB.
+	Color string
+}
+
+const (
+	defaultWaveformWidth  = 1920
+	defaultWaveformHeight = 240
+	defaultWaveformColor  = "#3b82f6"
+)
+
+var waveformColorRegex = regexp.MustCompile(`^([a-zA-Z]+|(#|0x)[0-9a-fA-F]{6}([0-9a-fA-F]{2})?)(@[01](\.\d+)?)?$`)
+
+func Waveform(opts *WaveformOptions) error {
+	return defaultConverter.Waveform(context.Background(), opts)
+}
+
+// ResolveWaveformOptions fills in the default size and color, and names the
+// output name_waveform.png next to the input.
+func ResolveWaveformOptions(opts *WaveformOptions) {
+	if opts.Output == "" {
+		opts.Output = trimExtension(opts.Input) + "_waveform.png"
+	}
+	if opts.Width == 0 {
+		opts.Width = defaultWaveformWidth
+	}
+	if opts.Height == 0 {
+		opts.Height = defaultWaveformHeight
+	}
+	if opts.Color == "" {
+		opts.Color = defaultWaveformColor
+	}
+}
+
+// ValidateWaveformOptions checks opts before running. Every error it
+// returns matches ErrInvalidOptions.
+func ValidateWaveformOptions(opts *WaveformOptions) error {
+	return invalidOptions(validateWaveformOptions(opts))
+}
+
+func validateWaveformOptions(opts *WaveformOptions) error {
+	if _, err := os.Stat(opts.Input); os.IsNotExist(err) {
+		return fmt.Errorf("input file does not exist: %s", opts.Input)
+	}
+	if ext := getExtension(opts.Output); !imageFormats[ext] {
+		return fmt.Errorf("unsupported image format: %s (supported: png, jpg, jpeg, bmp, tiff, webp)", ext)
+	}
+	if opts.Width <= 0 || opts.Height <= 0 {
+		return fmt.Errorf("invalid waveform size: %dx%d (must be positive)", opts.Width, opts.Height)
+	}
+	if !waveformColorRegex.MatchString(opts.Color) {
+		return fmt.Errorf("invalid color: %s (examples: white, #3b82f6, 0xff0000@0.5)", opts.Color)
+	}
+	return nil
+}
+
+// Waveform renders the first audio track of the input, mixed to mono, as a
+// single image.
+func (c *Converter) Waveform(ctx context.Context, opts *WaveformOptions) error {
+	ResolveWaveformOptions(opts)
+	if err := ValidateWaveformOptions(opts); err != nil {
+		return err
+	}
+
+	info, err := c.Probe(opts.Input)
+	if err != nil {
+		return fmt.Errorf("cannot read input %s, it may be corrupt or not a media file: %w", opts.Input, err)
+	}
+	if len(info.StreamsOfType("audio")) == 0 {
+		return fmt.Errorf("input has no audio: %s", opts.Input)
+	}
+
+	size := strconv.Itoa(opts.Width) + "x" + strconv.Itoa(opts.Height)
+	args := []string{
+		"-i", opts.Input, "-y",
+		"-filter_complex", "[0:a:0]aformat=channel_layouts=mono,showwavespic=s=" + size + ":colors=" + opts.Color,
+		"-frames:v", "1",
+		opts.Output,
+	}
+	if err := c.runFFmpeg(ctx, args, progressTotal{}, nil); err != nil {
+		return fmt.Errorf("ffmpeg waveform failed: %w", err)
+	}
+	return nil
+}
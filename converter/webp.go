@@ -0,0 +1,112 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+)
+
+// WebPOptions describes an animated WebP made from a video, a smaller
+// alternative to GIF for short looping clips.
+type WebPOptions struct {
+	Input  string
+	Output string
+
+	// FPS is the frame rate of the animation (default 15).
+	FPS float64
+
+	// Quality is libwebp's quality factor from 0 to 100 (default 75).
+	Quality int
+
+	// Loop is the loop count stored in the file; 0 loops forever.
+	Loop int
+}
+
+const (
+	defaultWebPFPS     = 15
+	defaultWebPQuality = 75
+
+	webpEncoder = "libwebp_anim"
+)
+
+func AnimatedWebP(opts *WebPOptions, onProgress ProgressFunc) error {
+	return defaultConverter.AnimatedWebP(context.Background(), opts, onProgress)
+}
+
+// ResolveWebPOptions fills in the default frame rate and quality, and names
+// the output name.webp next to the input.
+func ResolveWebPOptions(opts *WebPOptions) {
+	if opts.Output == "" {
+		opts.Output = trimExtension(opts.Input) + ".webp"
+	}
+	if opts.FPS == 0 {
+		opts.FPS = defaultWebPFPS
+	}
+	if opts.Quality == 0 {
+		opts.Quality = defaultWebPQuality
+	}
+}
+
+// ValidateWebPOptions checks opts before running. Every error it returns
+// matches ErrInvalidOptions.
+func ValidateWebPOptions(opts *WebPOptions) error {
+	return invalidOptions(validateWebPOptions(opts))
+}
+
+func validateWebPOptions(opts *WebPOptions) error {
+	if _, err := os.Stat(opts.Input); os.IsNotExist(err) {
+		return fmt.Errorf("input file does not exist: %s", opts.Input)
+	}
+	if ext := getExtension(opts.Output); ext != "webp" {
+		return fmt.Errorf("unsupported animation format: %s (supported: webp)", ext)
+	}
+	if opts.FPS < 0 || opts.FPS > 60 {
+		return fmt.Errorf("invalid fps: %g (must be between 0 and 60, e.g. 15)", opts.FPS)
+	}
+	if opts.Quality < 0 || opts.Quality > 100 {
+		return fmt.Errorf("invalid webp quality: %d (must be 0-100, higher is better)", opts.Quality)
+	}
+	if opts.Loop < 0 {
+		return fmt.Errorf("invalid loop count: %d (must be 0 or greater, 0 loops forever)", opts.Loop)
+	}
+	return nil
+}
+
+// AnimatedWebP encodes the input's video as an animated WebP in a single
+// pass. Unlike GIF, WebP needs no palette: libwebp_anim encodes full color.
+func (c *Converter) AnimatedWebP(ctx context.Context, opts *WebPOptions, onProgress ProgressFunc) error {
+	ResolveWebPOptions(opts)
+	if err := ValidateWebPOptions(opts); err != nil {
+		return err
+	}
+
+	// When the encoders can't be listed, ffmpeg reports a missing one itself.
+	if encoders, err := c.Encoders(); err == nil && !slices.Contains(encoders, webpEncoder) {
+		return fmt.Errorf("encoder %s is not available in this ffmpeg build (it needs ffmpeg built with --enable-libwebp)", webpEncoder)
+	}
+
+	info, err := c.Probe(opts.Input)
+	if err != nil {
+		return fmt.Errorf("cannot read input %s, it may be corrupt or not a media file: %w", opts.Input, err)
+	}
+	if info.VideoStream() == nil {
+		return fmt.Errorf("input has no video: %s", opts.Input)
+	}
+
+	args := []string{
+		"-i", opts.Input, "-y", "-progress", "pipe:2", "-nostats",
+		"-map", "0:v:0",
+		"-vf", "fps=" + strconv.FormatFloat(opts.FPS, 'f', -1, 64),
+		"-c:v", webpEncoder,
+		"-quality", strconv.Itoa(opts.Quality),
+		"-loop", strconv.Itoa(opts.Loop),
+		"-an",
+		opts.Output,
+	}
+	if err := c.runFFmpeg(ctx, args, progressTotal{duration: info.Duration}, progressReporter(onProgress, nil)); err != nil {
+		return fmt.Errorf("ffmpeg webp encoding failed: %w", err)
+	}
+	return nil
+}